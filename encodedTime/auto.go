@@ -0,0 +1,216 @@
+package encodedTime
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Encoding names the representation of an Auto timestamp
+type Encoding uint32
+
+// The encodings Auto can detect and produce
+const (
+	EncodingDefault Encoding = iota // use Auto.Default, EncodingSeconds if that is unset, too
+	EncodingSeconds
+	EncodingMillis
+	EncodingMicros
+	EncodingNanos
+	EncodingRFC3339
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingDefault:
+		return "default"
+	case EncodingSeconds:
+		return "seconds"
+	case EncodingMillis:
+		return "milliseconds"
+	case EncodingMicros:
+		return "microseconds"
+	case EncodingNanos:
+		return "nanoseconds"
+	case EncodingRFC3339:
+		return "rfc3339"
+	}
+	return fmt.Sprintf("Encoding(%d)", uint32(e))
+}
+
+// unit returns the duration one step of a numeric encoding represents
+func (e Encoding) unit() time.Duration {
+	switch e {
+	case EncodingSeconds:
+		return time.Second
+	case EncodingMillis:
+		return time.Millisecond
+	case EncodingMicros:
+		return time.Microsecond
+	case EncodingNanos:
+		return time.Nanosecond
+	}
+	return 0
+}
+
+// Auto detects the unit of numeric timestamps by their magnitude and also accepts RFC3339 strings.
+// It remembers what it was decoded from and marshals back into the same encoding.
+//
+// Magnitudes below 1e11 are seconds (up to the year 5138), below 1e14 milliseconds,
+// below 1e17 microseconds and everything above nanoseconds.
+//
+// Values without a detected encoding, like the zero value or one only decoded from null,
+// are marshaled with Default, which in turn falls back to EncodingSeconds.
+type Auto struct {
+	Time     time.Time
+	Encoding Encoding
+	Default  Encoding
+}
+
+// NewAuto returns an Auto that marshals t using the encoding e
+func NewAuto(t time.Time, e Encoding) Auto {
+	return Auto{Time: t, Encoding: e}
+}
+
+// NewAutoWithDefault returns an Auto that marshals t using def until it is decoded from something else.
+// def has to be one of the concrete encodings.
+func NewAutoWithDefault(t time.Time, def Encoding) (Auto, error) {
+	if def == EncodingDefault || def > EncodingRFC3339 {
+		return Auto{}, fmt.Errorf("encodedTime: invalid default encoding: %s", def)
+	}
+	return Auto{Time: t, Default: def}, nil
+}
+
+// detectEncoding guesses the unit of the numeric timestamp in
+func detectEncoding(in []byte) (Encoding, error) {
+	if !jsonNumber.Match(in) {
+		return EncodingDefault, fmt.Errorf("encodedTime: invalid number %q", in)
+	}
+	f, err := strconv.ParseFloat(string(in), 64)
+	if err != nil && !math.IsInf(f, 0) {
+		return EncodingDefault, err
+	}
+	switch f = math.Abs(f); {
+	case f < 1e11:
+		return EncodingSeconds, nil
+	case f < 1e14:
+		return EncodingMillis, nil
+	case f < 1e17:
+		return EncodingMicros, nil
+	}
+	return EncodingNanos, nil
+}
+
+// decodeAuto parses numbers (with detected unit) and RFC3339 strings
+func decodeAuto(in []byte) (time.Time, Encoding, error) {
+	enc, err := detectEncoding(in)
+	if err == nil {
+		t, err := parseUnits(in, enc.unit())
+		return t, enc, err
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, string(in))
+	if err != nil {
+		return time.Time{}, EncodingDefault, fmt.Errorf("encodedTime: %q is neither a number nor a RFC3339 time", in)
+	}
+	return t, EncodingRFC3339, nil
+}
+
+// UnmarshalJSON accepts numbers, RFC3339 strings and numbers in strings. null is a no-op.
+// Quoted numbers are recorded with their unit and marshaled back unquoted.
+func (t *Auto) UnmarshalJSON(in []byte) error {
+	in = bytes.TrimSpace(in)
+	if bytes.Equal(in, jsonNull) {
+		return nil
+	}
+
+	if len(in) > 0 && in[0] == '"' {
+		var s string
+		if err := json.Unmarshal(in, &s); err != nil {
+			return fmt.Errorf("encodedTime: invalid string %q: %w", in, err)
+		}
+		in = []byte(strings.TrimSpace(s))
+	}
+
+	tv, enc, err := decodeAuto(in)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Encoding = tv, enc
+	return nil
+}
+
+// resolved returns the encoding to marshal with
+func (t Auto) resolved() Encoding {
+	if t.Encoding != EncodingDefault {
+		return t.Encoding
+	}
+	if t.Default != EncodingDefault {
+		return t.Default
+	}
+	return EncodingSeconds
+}
+
+// MarshalJSON uses the detected encoding or Default if there is none
+func (t Auto) MarshalJSON() ([]byte, error) {
+	enc := t.resolved()
+	if enc == EncodingRFC3339 {
+		return json.Marshal(t.Time.Format(time.RFC3339Nano))
+	}
+	if enc.unit() == 0 {
+		return nil, fmt.Errorf("encodedTime: invalid encoding: %s", enc)
+	}
+	return marshalUnits(t.Time, enc.unit())
+}
+
+// Scan implements sql.Scanner. Numbers are detected like in UnmarshalJSON,
+// time.Time values and textual timestamps are recorded as EncodingRFC3339.
+func (t *Auto) Scan(src interface{}) error {
+	var in []byte
+	switch v := src.(type) {
+	case int64:
+		in = []byte(strconv.FormatInt(v, 10))
+	case float64:
+		in = []byte(strconv.FormatFloat(v, 'g', -1, 64))
+	case []byte:
+		in = bytes.TrimSpace(v)
+	case string:
+		in = []byte(strings.TrimSpace(v))
+	}
+
+	if in != nil {
+		if enc, err := detectEncoding(in); err == nil {
+			tv, err := parseUnits(in, enc.unit())
+			if err != nil {
+				return err
+			}
+			t.Time, t.Encoding = tv, enc
+			return nil
+		}
+	}
+
+	tv, err := scanTime(src, time.Second)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Encoding = tv, EncodingRFC3339
+	return nil
+}
+
+// Value implements driver.Valuer. Numeric encodings are stored as integers, EncodingRFC3339 as text.
+func (t Auto) Value() (driver.Value, error) {
+	enc := t.resolved()
+	if enc == EncodingRFC3339 {
+		return t.Time.Format(time.RFC3339Nano), nil
+	}
+	if enc.unit() == 0 {
+		return nil, fmt.Errorf("encodedTime: invalid encoding: %s", enc)
+	}
+	return formatUnits(t.Time, enc.unit())
+}
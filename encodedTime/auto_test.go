@@ -0,0 +1,145 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAutoDetect(t *testing.T) {
+	want := time.Unix(1600000000, 0)
+	tcases := []struct {
+		in   string
+		enc  Encoding
+		want time.Time
+	}{
+		{`1600000000`, EncodingSeconds, want},
+		{`1600000000.25`, EncodingSeconds, want.Add(250 * time.Millisecond)},
+		{`1600000000123`, EncodingMillis, want.Add(123 * time.Millisecond)},
+		{`1600000000123456`, EncodingMicros, want.Add(123456 * time.Microsecond)},
+		{`1600000000123456789`, EncodingNanos, want.Add(123456789)},
+		{`"1600000000123"`, EncodingMillis, want.Add(123 * time.Millisecond)},
+		{`"2020-09-13T12:26:40.5Z"`, EncodingRFC3339, want.Add(500 * time.Millisecond)},
+		{`-86400`, EncodingSeconds, time.Unix(-86400, 0)},
+	}
+
+	for _, tc := range tcases {
+		var a Auto
+		if err := json.Unmarshal([]byte(tc.in), &a); err != nil {
+			t.Errorf("%s: %s", tc.in, err)
+			continue
+		}
+		if a.Encoding != tc.enc {
+			t.Errorf("%s: wrong encoding %s, want %s", tc.in, a.Encoding, tc.enc)
+		}
+		if !a.Time.Equal(tc.want) {
+			t.Errorf("%s: got %s, want %s", tc.in, a.Time, tc.want)
+		}
+	}
+}
+
+func TestAutoRoundtrip(t *testing.T) {
+	for _, in := range []string{
+		`1600000000`,
+		`1600000000123`,
+		`1600000000123456`,
+		`1600000000123456789`,
+		`"2020-09-13T12:26:40.5Z"`,
+		`"2020-09-13T14:26:40+02:00"`,
+	} {
+		var a Auto
+		if err := json.Unmarshal([]byte(in), &a); err != nil {
+			t.Fatalf("%s: %s", in, err)
+		}
+		out, err := json.Marshal(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != in {
+			t.Errorf("%s: got %s", in, out)
+		}
+	}
+}
+
+func TestAutoDefault(t *testing.T) {
+	a := Auto{Time: time.Unix(1600000000, 0)}
+
+	out, err := json.Marshal(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `1600000000` {
+		t.Errorf("got %s", out)
+	}
+
+	ms, err := NewAutoWithDefault(a.Time, EncodingMillis)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err = json.Marshal(ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `1600000000000` {
+		t.Errorf("got %s", out)
+	}
+
+	// the default is per value and survives a null
+	var v struct{ A, B Auto }
+	v.A.Default = EncodingRFC3339
+	if err := json.Unmarshal([]byte(`{"A":null,"B":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	v.A.Time = a.Time.UTC()
+	v.B.Time = a.Time
+	out, err = json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"A":"2020-09-13T12:26:40Z","B":1600000000}` {
+		t.Errorf("got %s", out)
+	}
+
+	for _, e := range []Encoding{EncodingDefault, EncodingRFC3339 + 1} {
+		if _, err := NewAutoWithDefault(a.Time, e); err == nil {
+			t.Errorf("%s: expected error", e)
+		}
+	}
+
+	out, err = json.Marshal(NewAuto(a.Time.UTC(), EncodingRFC3339))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"2020-09-13T12:26:40Z"` {
+		t.Errorf("got %s", out)
+	}
+}
+
+func TestAutoScan(t *testing.T) {
+	var a Auto
+	if err := a.Scan(int64(1600000000123)); err != nil {
+		t.Fatal(err)
+	}
+	if a.Encoding != EncodingMillis {
+		t.Errorf("wrong encoding: %s", a.Encoding)
+	}
+	if v, err := a.Value(); err != nil || v != int64(1600000000123) {
+		t.Errorf("wrong value: %v %v", v, err)
+	}
+
+	if err := a.Scan("2020-09-13 12:26:40"); err != nil {
+		t.Fatal(err)
+	}
+	if a.Encoding != EncodingRFC3339 || !a.Time.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("wrong time: %+v", a)
+	}
+}
+
+func TestAutoInvalid(t *testing.T) {
+	for _, in := range []string{`"yesterday"`, `true`, `""`, `1e400`} {
+		var a Auto
+		if err := json.Unmarshal([]byte(in), &a); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+	}
+}
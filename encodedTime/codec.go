@@ -0,0 +1,603 @@
+package encodedTime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// This file implements the non-JSON codecs for all types of this package:
+// encoding.TextMarshaler and TextUnmarshaler (URL query parameters, YAML and TOML keys, ...),
+// encoding.BinaryMarshaler and BinaryUnmarshaler (gob, CBOR, msgpack),
+// flag.Value and the Marshaler/Unmarshaler interfaces of gopkg.in/yaml.v2 and v3.
+//
+// The text form is the JSON form without quotes, the binary form is the same integer as a
+// big-endian int64, so every codec keeps exactly the same precision as the JSON encoding.
+
+// decodeText is the shared UnmarshalText implementation. An empty text counts as null.
+// If lenient is set the text is treated like a JSON string, otherwise like a bare JSON number.
+func decodeText(in []byte, unit time.Duration, integral, lenient bool) (time.Time, bool, error) {
+	in = bytes.TrimSpace(in)
+	if len(in) == 0 {
+		return time.Time{}, true, nil
+	}
+	if lenient {
+		quoted, err := json.Marshal(string(in))
+		if err != nil {
+			return time.Time{}, false, err
+		}
+		return decodeJSON(quoted, unit, integral, true)
+	}
+	return decodeJSON(in, unit, integral, false)
+}
+
+// decodeYAML is the shared UnmarshalYAML implementation.
+// The YAML value is converted to text and decoded with decodeText, time.Time values are taken as they are.
+func decodeYAML(unmarshal func(interface{}) error, unit time.Duration, integral, lenient bool) (time.Time, bool, error) {
+	text, tv, err := yamlText(unmarshal)
+	if err != nil || text == nil {
+		return tv, tv.IsZero(), err
+	}
+	return decodeText(text, unit, integral, lenient)
+}
+
+// yamlText returns the textual form of a YAML scalar, or the time if the decoder already resolved a timestamp.
+// Both are nil for a YAML null.
+func yamlText(unmarshal func(interface{}) error) ([]byte, time.Time, error) {
+	var v interface{}
+	if err := unmarshal(&v); err != nil {
+		return nil, time.Time{}, err
+	}
+	switch x := v.(type) {
+	case nil:
+		return nil, time.Time{}, nil
+	case time.Time:
+		return nil, x, nil
+	case string:
+		return []byte(x), time.Time{}, nil
+	case int:
+		return []byte(strconv.Itoa(x)), time.Time{}, nil
+	case int64:
+		return []byte(strconv.FormatInt(x, 10)), time.Time{}, nil
+	case uint64:
+		return []byte(strconv.FormatUint(x, 10)), time.Time{}, nil
+	case float64:
+		return []byte(strconv.FormatFloat(x, 'g', -1, 64)), time.Time{}, nil
+	}
+	return nil, time.Time{}, fmt.Errorf("encodedTime: can't decode YAML value of type %T", v)
+}
+
+// marshalBinaryUnits is the shared MarshalBinary implementation of the numeric types
+func marshalBinaryUnits(t time.Time, unit time.Duration) ([]byte, error) {
+	n, err := formatUnits(t, unit)
+	if err != nil {
+		return nil, err
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(n))
+	return buf[:], nil
+}
+
+// unmarshalBinaryUnits is the shared UnmarshalBinary implementation of the numeric types
+func unmarshalBinaryUnits(data []byte, unit time.Duration) (time.Time, error) {
+	if len(data) != 8 {
+		return time.Time{}, fmt.Errorf("encodedTime: invalid binary length %d", len(data))
+	}
+	return fromUnits(int64(binary.BigEndian.Uint64(data)), unit), nil
+}
+
+// MarshalText returns the same digits as MarshalJSON
+func (t Unix) MarshalText() ([]byte, error) {
+	return t.MarshalJSON()
+}
+
+// UnmarshalText decodes like UnmarshalJSON, an empty text is a no-op
+func (t *Unix) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Second, true, false)
+	if err != nil || null {
+		return err
+	}
+
+	*t = Unix(tv)
+	return nil
+}
+
+// MarshalBinary returns the seconds since unix-0 as a big-endian int64
+func (t Unix) MarshalBinary() ([]byte, error) {
+	return marshalBinaryUnits(time.Time(t), time.Second)
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary
+func (t *Unix) UnmarshalBinary(data []byte) error {
+	tv, err := unmarshalBinaryUnits(data, time.Second)
+	if err != nil {
+		return err
+	}
+
+	*t = Unix(tv)
+	return nil
+}
+
+// String returns the text form, which makes *Unix a flag.Value together with Set
+func (t Unix) String() string {
+	out, err := t.MarshalText()
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// Set implements flag.Value
+func (t *Unix) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// MarshalYAML emits the seconds since unix-0 as a YAML integer
+func (t Unix) MarshalYAML() (interface{}, error) {
+	return formatUnits(time.Time(t), time.Second)
+}
+
+// UnmarshalYAML decodes YAML numbers and strings like UnmarshalText
+func (t *Unix) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Second, true, false)
+	if err != nil || null {
+		return err
+	}
+
+	*t = Unix(tv)
+	return nil
+}
+
+// MarshalText returns the same digits as MarshalJSON
+func (t Millisecs) MarshalText() ([]byte, error) {
+	return t.MarshalJSON()
+}
+
+// UnmarshalText decodes like UnmarshalJSON, an empty text is a no-op
+func (t *Millisecs) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Millisecond, false, false)
+	if err != nil || null {
+		return err
+	}
+
+	*t = Millisecs(tv)
+	return nil
+}
+
+// MarshalBinary returns the milliseconds since unix-0 as a big-endian int64
+func (t Millisecs) MarshalBinary() ([]byte, error) {
+	return marshalBinaryUnits(time.Time(t), time.Millisecond)
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary
+func (t *Millisecs) UnmarshalBinary(data []byte) error {
+	tv, err := unmarshalBinaryUnits(data, time.Millisecond)
+	if err != nil {
+		return err
+	}
+
+	*t = Millisecs(tv)
+	return nil
+}
+
+// String returns the text form, which makes *Millisecs a flag.Value together with Set
+func (t Millisecs) String() string {
+	out, err := t.MarshalText()
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// Set implements flag.Value
+func (t *Millisecs) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// MarshalYAML emits the milliseconds since unix-0 as a YAML integer
+func (t Millisecs) MarshalYAML() (interface{}, error) {
+	return formatUnits(time.Time(t), time.Millisecond)
+}
+
+// UnmarshalYAML decodes YAML numbers and strings like UnmarshalText
+func (t *Millisecs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Millisecond, false, false)
+	if err != nil || null {
+		return err
+	}
+
+	*t = Millisecs(tv)
+	return nil
+}
+
+// MarshalText returns the same digits as MarshalJSON
+func (t Microsecs) MarshalText() ([]byte, error) {
+	return t.MarshalJSON()
+}
+
+// UnmarshalText decodes like UnmarshalJSON, an empty text is a no-op
+func (t *Microsecs) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Microsecond, false, false)
+	if err != nil || null {
+		return err
+	}
+
+	*t = Microsecs(tv)
+	return nil
+}
+
+// MarshalBinary returns the microseconds since unix-0 as a big-endian int64
+func (t Microsecs) MarshalBinary() ([]byte, error) {
+	return marshalBinaryUnits(time.Time(t), time.Microsecond)
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary
+func (t *Microsecs) UnmarshalBinary(data []byte) error {
+	tv, err := unmarshalBinaryUnits(data, time.Microsecond)
+	if err != nil {
+		return err
+	}
+
+	*t = Microsecs(tv)
+	return nil
+}
+
+// String returns the text form, which makes *Microsecs a flag.Value together with Set
+func (t Microsecs) String() string {
+	out, err := t.MarshalText()
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// Set implements flag.Value
+func (t *Microsecs) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// MarshalYAML emits the microseconds since unix-0 as a YAML integer
+func (t Microsecs) MarshalYAML() (interface{}, error) {
+	return formatUnits(time.Time(t), time.Microsecond)
+}
+
+// UnmarshalYAML decodes YAML numbers and strings like UnmarshalText
+func (t *Microsecs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Microsecond, false, false)
+	if err != nil || null {
+		return err
+	}
+
+	*t = Microsecs(tv)
+	return nil
+}
+
+// MarshalText returns the same digits as MarshalJSON
+func (t Nanosecs) MarshalText() ([]byte, error) {
+	return t.MarshalJSON()
+}
+
+// UnmarshalText decodes like UnmarshalJSON, an empty text is a no-op
+func (t *Nanosecs) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Nanosecond, false, false)
+	if err != nil || null {
+		return err
+	}
+
+	*t = Nanosecs(tv)
+	return nil
+}
+
+// MarshalBinary returns the nanoseconds since unix-0 as a big-endian int64
+func (t Nanosecs) MarshalBinary() ([]byte, error) {
+	return marshalBinaryUnits(time.Time(t), time.Nanosecond)
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary
+func (t *Nanosecs) UnmarshalBinary(data []byte) error {
+	tv, err := unmarshalBinaryUnits(data, time.Nanosecond)
+	if err != nil {
+		return err
+	}
+
+	*t = Nanosecs(tv)
+	return nil
+}
+
+// String returns the text form, which makes *Nanosecs a flag.Value together with Set
+func (t Nanosecs) String() string {
+	out, err := t.MarshalText()
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// Set implements flag.Value
+func (t *Nanosecs) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// MarshalYAML emits the nanoseconds since unix-0 as a YAML integer
+func (t Nanosecs) MarshalYAML() (interface{}, error) {
+	return formatUnits(time.Time(t), time.Nanosecond)
+}
+
+// UnmarshalYAML decodes YAML numbers and strings like UnmarshalText
+func (t *Nanosecs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Nanosecond, false, false)
+	if err != nil || null {
+		return err
+	}
+
+	*t = Nanosecs(tv)
+	return nil
+}
+
+// MarshalText returns an empty text if Valid is false, otherwise it's the same as Unix
+func (t NullUnix) MarshalText() ([]byte, error) {
+	if !t.Valid {
+		return []byte{}, nil
+	}
+	return Unix(t.Time).MarshalText()
+}
+
+// UnmarshalText sets Valid to false for empty texts, otherwise it decodes like Unix
+func (t *NullUnix) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Second, true, false)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// MarshalBinary returns no bytes if Valid is false, otherwise it's the same as Unix
+func (t NullUnix) MarshalBinary() ([]byte, error) {
+	if !t.Valid {
+		return []byte{}, nil
+	}
+	return Unix(t.Time).MarshalBinary()
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary
+func (t *NullUnix) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		t.Time, t.Valid = time.Time{}, false
+		return nil
+	}
+
+	tv, err := unmarshalBinaryUnits(data, time.Second)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, true
+	return nil
+}
+
+// String returns the text form, which makes *NullUnix a flag.Value together with Set
+func (t NullUnix) String() string {
+	out, err := t.MarshalText()
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// Set implements flag.Value
+func (t *NullUnix) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// MarshalYAML emits null if Valid is false, otherwise it's the same as Unix
+func (t NullUnix) MarshalYAML() (interface{}, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return Unix(t.Time).MarshalYAML()
+}
+
+// UnmarshalYAML sets Valid to false for null and empty strings, otherwise it decodes like Unix
+func (t *NullUnix) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Second, true, false)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// MarshalText returns an empty text if Valid is false, otherwise it's the same as Millisecs
+func (t NullMillisecs) MarshalText() ([]byte, error) {
+	if !t.Valid {
+		return []byte{}, nil
+	}
+	return Millisecs(t.Time).MarshalText()
+}
+
+// UnmarshalText sets Valid to false for empty texts, otherwise it decodes like Millisecs
+func (t *NullMillisecs) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Millisecond, false, false)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// MarshalBinary returns no bytes if Valid is false, otherwise it's the same as Millisecs
+func (t NullMillisecs) MarshalBinary() ([]byte, error) {
+	if !t.Valid {
+		return []byte{}, nil
+	}
+	return Millisecs(t.Time).MarshalBinary()
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary
+func (t *NullMillisecs) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		t.Time, t.Valid = time.Time{}, false
+		return nil
+	}
+
+	tv, err := unmarshalBinaryUnits(data, time.Millisecond)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, true
+	return nil
+}
+
+// String returns the text form, which makes *NullMillisecs a flag.Value together with Set
+func (t NullMillisecs) String() string {
+	out, err := t.MarshalText()
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// Set implements flag.Value
+func (t *NullMillisecs) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// MarshalYAML emits null if Valid is false, otherwise it's the same as Millisecs
+func (t NullMillisecs) MarshalYAML() (interface{}, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return Millisecs(t.Time).MarshalYAML()
+}
+
+// UnmarshalYAML sets Valid to false for null and empty strings, otherwise it decodes like Millisecs
+func (t *NullMillisecs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Millisecond, false, false)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// MarshalText returns the JSON form without quotes
+func (t Auto) MarshalText() ([]byte, error) {
+	if t.resolved() == EncodingRFC3339 {
+		return []byte(t.Time.Format(time.RFC3339Nano)), nil
+	}
+	return t.MarshalJSON()
+}
+
+// UnmarshalText detects the encoding like UnmarshalJSON, an empty text is a no-op
+func (t *Auto) UnmarshalText(in []byte) error {
+	in = bytes.TrimSpace(in)
+	if len(in) == 0 {
+		return nil
+	}
+
+	tv, enc, err := decodeAuto(in)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Encoding = tv, enc
+	return nil
+}
+
+// MarshalBinary returns the encoding as the first byte, followed by the big-endian int64 of the numeric encodings
+// or time.Time's binary form for EncodingRFC3339.
+func (t Auto) MarshalBinary() ([]byte, error) {
+	enc := t.resolved()
+
+	var (
+		data []byte
+		err  error
+	)
+	if enc == EncodingRFC3339 {
+		data, err = t.Time.MarshalBinary()
+	} else if enc.unit() != 0 {
+		data, err = marshalBinaryUnits(t.Time, enc.unit())
+	} else {
+		err = fmt.Errorf("encodedTime: invalid encoding: %s", enc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{byte(enc)}, data...), nil
+}
+
+// UnmarshalBinary decodes the output of MarshalBinary
+func (t *Auto) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return fmt.Errorf("encodedTime: empty binary Auto")
+	}
+
+	var (
+		enc = Encoding(data[0])
+		tv  time.Time
+		err error
+	)
+	if enc == EncodingRFC3339 {
+		err = tv.UnmarshalBinary(data[1:])
+	} else if enc.unit() != 0 {
+		tv, err = unmarshalBinaryUnits(data[1:], enc.unit())
+	} else {
+		err = fmt.Errorf("encodedTime: invalid encoding: %s", enc)
+	}
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Encoding = tv, enc
+	return nil
+}
+
+// String returns the text form, which makes *Auto a flag.Value together with Set
+func (t Auto) String() string {
+	out, err := t.MarshalText()
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+// Set implements flag.Value
+func (t *Auto) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// MarshalYAML emits a YAML integer for the numeric encodings and a string for EncodingRFC3339
+func (t Auto) MarshalYAML() (interface{}, error) {
+	enc := t.resolved()
+	if enc == EncodingRFC3339 {
+		return t.Time.Format(time.RFC3339Nano), nil
+	}
+	if enc.unit() == 0 {
+		return nil, fmt.Errorf("encodedTime: invalid encoding: %s", enc)
+	}
+	return formatUnits(t.Time, enc.unit())
+}
+
+// UnmarshalYAML detects the encoding like UnmarshalText. Timestamps the YAML decoder already resolved are recorded as EncodingRFC3339.
+func (t *Auto) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	text, tv, err := yamlText(unmarshal)
+	if err != nil {
+		return err
+	}
+	if text == nil {
+		if !tv.IsZero() {
+			t.Time, t.Encoding = tv, EncodingRFC3339
+		}
+		return nil
+	}
+	return t.UnmarshalText(text)
+}
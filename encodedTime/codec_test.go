@@ -0,0 +1,220 @@
+package encodedTime
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/gob"
+	"flag"
+	"io/ioutil"
+	"net/url"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+var (
+	_ encoding.TextMarshaler     = Unix{}
+	_ encoding.TextUnmarshaler   = (*Millisecs)(nil)
+	_ encoding.BinaryMarshaler   = Microsecs{}
+	_ encoding.BinaryUnmarshaler = (*Nanosecs)(nil)
+	_ flag.Value                 = (*NullUnix)(nil)
+	_ flag.Value                 = (*NullMillisecs)(nil)
+	_ flag.Value                 = (*Auto)(nil)
+)
+
+func TestTextQueryParams(t *testing.T) {
+	ts := time.Unix(1600000000, 123456789)
+
+	out, err := Millisecs(ts).MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	q := url.Values{}
+	q.Set("since", string(out))
+	if enc := q.Encode(); enc != "since=1600000000123" {
+		t.Fatalf("wrong query: %s", enc)
+	}
+
+	var ms Millisecs
+	if err := ms.UnmarshalText([]byte(q.Get("since"))); err != nil {
+		t.Fatal(err)
+	}
+	if !time.Time(ms).Equal(ts.Truncate(time.Millisecond)) {
+		t.Fatalf("wrong time: %s", time.Time(ms))
+	}
+
+	var nu NullUnix
+	if err := nu.UnmarshalText([]byte("")); err != nil {
+		t.Fatal(err)
+	}
+	if nu.Valid {
+		t.Fatal("empty text should be invalid")
+	}
+}
+
+func TestFlags(t *testing.T) {
+	var (
+		u  Unix
+		nm NullMillisecs
+		a  Auto
+	)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.Var(&u, "unix", "a unix timestamp")
+	fs.Var(&nm, "ms", "an optional millisecond timestamp")
+	fs.Var(&a, "auto", "any timestamp")
+
+	err := fs.Parse([]string{"-unix", "1600000000", "-auto", "2020-09-13T12:26:40Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := time.Unix(1600000000, 0)
+	if !time.Time(u).Equal(want) {
+		t.Errorf("wrong unix: %s", u)
+	}
+	if nm.Valid {
+		t.Errorf("ms should be unset: %s", nm)
+	}
+	if a.Encoding != EncodingRFC3339 || !a.Time.Equal(want) {
+		t.Errorf("wrong auto: %+v", a)
+	}
+	if u.String() != "1600000000" || a.String() != "2020-09-13T12:26:40Z" {
+		t.Errorf("wrong String(): %s %s", u, a)
+	}
+
+	if err := fs.Parse([]string{"-unix", "soon"}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestGob(t *testing.T) {
+	type payload struct {
+		U  Unix
+		Ms Millisecs
+		Us Microsecs
+		Ns Nanosecs
+		NU NullUnix
+		NM NullMillisecs
+		A  Auto
+	}
+
+	ts := time.Unix(1600000000, 123456789)
+	in := payload{
+		U:  Unix(ts),
+		Ms: Millisecs(ts),
+		Us: Microsecs(ts),
+		Ns: Nanosecs(ts),
+		NU: NullUnix{},
+		NM: NullMillisecs{Time: ts, Valid: true},
+		A:  NewAuto(ts, EncodingMicros),
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(in); err != nil {
+		t.Fatal(err)
+	}
+
+	var out payload
+	if err := gob.NewDecoder(&buf).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		got, want time.Time
+	}{
+		{time.Time(out.U), ts.Truncate(time.Second)},
+		{time.Time(out.Ms), ts.Truncate(time.Millisecond)},
+		{time.Time(out.Us), ts.Truncate(time.Microsecond)},
+		{time.Time(out.Ns), ts},
+		{out.NM.Time, ts.Truncate(time.Millisecond)},
+		{out.A.Time, ts.Truncate(time.Microsecond)},
+	}
+	for i, c := range checks {
+		if !c.got.Equal(c.want) {
+			t.Errorf("%d: got %s, want %s", i, c.got, c.want)
+		}
+	}
+	if out.NU.Valid || !out.NM.Valid || out.A.Encoding != EncodingMicros {
+		t.Errorf("wrong flags: %+v", out)
+	}
+}
+
+func TestBinaryRoundtrip(t *testing.T) {
+	f := func(sec int64, nsec uint32) bool {
+		ts := time.Unix(sec%(1<<40), int64(nsec%1e9))
+
+		data, err := Nanosecs(ts).MarshalBinary()
+		if err != nil {
+			// outside of the int64 nanosecond range
+			return true
+		}
+		var ns Nanosecs
+		if err := ns.UnmarshalBinary(data); err != nil {
+			t.Log(err)
+			return false
+		}
+		return time.Time(ns).Equal(ts)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := new(Unix).UnmarshalBinary([]byte{1, 2, 3}); err == nil {
+		t.Fatal("expected error for short input")
+	}
+}
+
+// yamlValue mimics the unmarshal callback of the yaml packages for an already decoded value
+func yamlValue(v interface{}) func(interface{}) error {
+	return func(dst interface{}) error {
+		*(dst.(*interface{})) = v
+		return nil
+	}
+}
+
+func TestYAML(t *testing.T) {
+	want := time.Unix(1600000000, 0)
+
+	for _, v := range []interface{}{1600000000, int64(1600000000), uint64(1600000000), "1600000000", want} {
+		var u Unix
+		if err := u.UnmarshalYAML(yamlValue(v)); err != nil {
+			t.Errorf("%T: %s", v, err)
+			continue
+		}
+		if !time.Time(u).Equal(want) {
+			t.Errorf("%T: got %s", v, u)
+		}
+	}
+
+	var nu NullUnix
+	if err := nu.UnmarshalYAML(yamlValue(nil)); err != nil {
+		t.Fatal(err)
+	}
+	if nu.Valid {
+		t.Fatal("null should be invalid")
+	}
+	if v, err := nu.MarshalYAML(); err != nil || v != nil {
+		t.Fatalf("expected nil: %v %v", v, err)
+	}
+
+	var ms Millisecs
+	if err := ms.UnmarshalYAML(yamlValue(1600000000123.5)); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := ms.MarshalYAML(); err != nil || v != int64(1600000000123) {
+		t.Fatalf("wrong value: %v %v", v, err)
+	}
+
+	var a Auto
+	if err := a.UnmarshalYAML(yamlValue(1600000000123456)); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := a.MarshalYAML(); err != nil || v != int64(1600000000123456) {
+		t.Fatalf("wrong value: %v %v", v, err)
+	}
+
+	if err := new(Unix).UnmarshalYAML(yamlValue([]string{"nope"})); err == nil {
+		t.Fatal("expected error for sequence")
+	}
+}
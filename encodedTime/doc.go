@@ -0,0 +1,17 @@
+/*
+Package encodedTime implements time.Time wrappers that encode as numbers since the unix epoch.
+
+Unix, Millisecs, Microsecs and Nanosecs count seconds, milliseconds, microseconds and nanoseconds.
+NullUnix and NullMillisecs are their nullable counterparts and Auto detects the unit of its input.
+
+For durations there are Duration (Go syntax like "1m30s"), Seconds and Millis (numbers)
+and ISODuration (ISO 8601 like "PT1H30M").
+
+Formatted, Date and TimeOfDay encode as strings: a time in a fixed layout and zone,
+a calendar date (YYYY-MM-DD) and a wall clock time (HH:MM:SS).
+
+The timestamp types implement the JSON, text, binary and YAML (un)marshaler interfaces, flag.Value,
+sql.Scanner and driver.Valuer with the same precision, so they can be used in JSON payloads,
+URL query parameters, command-line flags, gob, CBOR and msgpack streams, YAML files and database columns alike.
+*/
+package encodedTime
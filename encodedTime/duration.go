@@ -0,0 +1,271 @@
+package encodedTime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration encodes a time.Duration as a Go duration string like "1m30s"
+type Duration time.Duration
+
+// UnmarshalJSON parses a string with time.ParseDuration. null is a no-op.
+// See LenientDuration for a variant that also accepts ISO 8601 durations.
+func (d *Duration) UnmarshalJSON(in []byte) error {
+	v, null, err := decodeGoDurationJSON(in, false)
+	if err != nil || null {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalJSON returns the quoted output of time.Duration.String()
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalText parses the text with time.ParseDuration
+func (d *Duration) UnmarshalText(in []byte) error {
+	v, null, err := parseGoDuration(string(in), false)
+	if err != nil || null {
+		return err
+	}
+
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText returns the output of time.Duration.String()
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// String returns the output of time.Duration.String(), which makes *Duration a flag.Value together with Set
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// Set implements flag.Value
+func (d *Duration) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+// Seconds encodes a time.Duration as a number of seconds.
+// Fractions are written only if needed and are kept down to the nanosecond.
+type Seconds time.Duration
+
+// UnmarshalJSON accepts integers, fractions and exponents. null is a no-op.
+func (d *Seconds) UnmarshalJSON(in []byte) error {
+	v, null, err := decodeDurationJSON(in, time.Second, false)
+	if err != nil || null {
+		return err
+	}
+
+	*d = Seconds(v)
+	return nil
+}
+
+// MarshalJSON returns the seconds as a decimal number
+func (d Seconds) MarshalJSON() ([]byte, error) {
+	return formatDuration(time.Duration(d), time.Second), nil
+}
+
+// UnmarshalText decodes like UnmarshalJSON, an empty text is a no-op
+func (d *Seconds) UnmarshalText(in []byte) error {
+	v, null, err := decodeDurationText(in, time.Second)
+	if err != nil || null {
+		return err
+	}
+
+	*d = Seconds(v)
+	return nil
+}
+
+// MarshalText returns the same digits as MarshalJSON
+func (d Seconds) MarshalText() ([]byte, error) {
+	return d.MarshalJSON()
+}
+
+// String returns the text form, which makes *Seconds a flag.Value together with Set
+func (d Seconds) String() string {
+	return string(formatDuration(time.Duration(d), time.Second))
+}
+
+// Set implements flag.Value
+func (d *Seconds) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+// Millis encodes a time.Duration as a number of milliseconds.
+// Fractions are written only if needed and are kept down to the nanosecond.
+type Millis time.Duration
+
+// UnmarshalJSON accepts integers, fractions and exponents. null is a no-op.
+func (d *Millis) UnmarshalJSON(in []byte) error {
+	v, null, err := decodeDurationJSON(in, time.Millisecond, false)
+	if err != nil || null {
+		return err
+	}
+
+	*d = Millis(v)
+	return nil
+}
+
+// MarshalJSON returns the milliseconds as a decimal number
+func (d Millis) MarshalJSON() ([]byte, error) {
+	return formatDuration(time.Duration(d), time.Millisecond), nil
+}
+
+// UnmarshalText decodes like UnmarshalJSON, an empty text is a no-op
+func (d *Millis) UnmarshalText(in []byte) error {
+	v, null, err := decodeDurationText(in, time.Millisecond)
+	if err != nil || null {
+		return err
+	}
+
+	*d = Millis(v)
+	return nil
+}
+
+// MarshalText returns the same digits as MarshalJSON
+func (d Millis) MarshalText() ([]byte, error) {
+	return d.MarshalJSON()
+}
+
+// String returns the text form, which makes *Millis a flag.Value together with Set
+func (d Millis) String() string {
+	return string(formatDuration(time.Duration(d), time.Millisecond))
+}
+
+// Set implements flag.Value
+func (d *Millis) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+// decodeGoDurationJSON is the shared UnmarshalJSON implementation of Duration and LenientDuration.
+// null is true for a JSON null or, if lenient is set, an empty string.
+func decodeGoDurationJSON(in []byte, lenient bool) (time.Duration, bool, error) {
+	in = bytes.TrimSpace(in)
+	if bytes.Equal(in, jsonNull) {
+		return 0, true, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(in, &s); err != nil {
+		return 0, false, fmt.Errorf("encodedTime: duration %q is not a string: %w", in, err)
+	}
+	return parseGoDuration(s, lenient)
+}
+
+// parseGoDuration parses s with time.ParseDuration.
+// If lenient is set, an empty string counts as null and ISO 8601 durations are accepted, too.
+func parseGoDuration(s string, lenient bool) (time.Duration, bool, error) {
+	s = strings.TrimSpace(s)
+	if lenient {
+		if s == "" {
+			return 0, true, nil
+		}
+		if iso, err := parseISODuration(s); err == nil {
+			return iso, false, nil
+		}
+	}
+
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, false, fmt.Errorf("encodedTime: %w", err)
+	}
+	return v, false, nil
+}
+
+// decodeDurationJSON is the shared UnmarshalJSON implementation of the numeric duration types.
+// null is true for a JSON null or, if lenient is set, an empty string.
+func decodeDurationJSON(in []byte, unit time.Duration, lenient bool) (time.Duration, bool, error) {
+	in = bytes.TrimSpace(in)
+	if bytes.Equal(in, jsonNull) {
+		return 0, true, nil
+	}
+
+	if lenient && len(in) > 0 && in[0] == '"' {
+		var s string
+		if err := json.Unmarshal(in, &s); err != nil {
+			return 0, false, fmt.Errorf("encodedTime: invalid string %q: %w", in, err)
+		}
+		in = []byte(strings.TrimSpace(s))
+		if len(in) == 0 {
+			return 0, true, nil
+		}
+	}
+
+	d, err := parseDuration(in, unit)
+	return d, false, err
+}
+
+// decodeDurationText is the shared UnmarshalText implementation of the numeric duration types. An empty text counts as null.
+func decodeDurationText(in []byte, unit time.Duration) (time.Duration, bool, error) {
+	in = bytes.TrimSpace(in)
+	if len(in) == 0 {
+		return 0, true, nil
+	}
+	d, err := parseDuration(in, unit)
+	return d, false, err
+}
+
+var (
+	minDuration = big.NewInt(int64(-1 << 63))
+	maxDuration = big.NewInt(int64(1<<63 - 1))
+)
+
+// parseDuration parses a decimal number of units. Fractions of a nanosecond are truncated.
+func parseDuration(in []byte, unit time.Duration) (time.Duration, error) {
+	m := jsonNumber.FindSubmatch(in)
+	if m == nil {
+		return 0, fmt.Errorf("encodedTime: invalid number %q", in)
+	}
+
+	if len(m[4]) > 0 {
+		exp, err := strconv.Atoi(string(m[4]))
+		if err != nil || exp > maxExponent {
+			return 0, fmt.Errorf("encodedTime: exponent of %q out of range", in)
+		}
+	}
+
+	r, ok := new(big.Rat).SetString(string(in))
+	if !ok {
+		return 0, fmt.Errorf("encodedTime: invalid number %q", in)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(int64(unit)))
+
+	ns := new(big.Int).Quo(r.Num(), r.Denom())
+	if ns.Cmp(minDuration) < 0 || ns.Cmp(maxDuration) > 0 {
+		return 0, fmt.Errorf("encodedTime: duration %q out of range", in)
+	}
+	return time.Duration(ns.Int64()), nil
+}
+
+// formatDuration writes d as an exact decimal number of units, without a fraction if it's a whole number
+func formatDuration(d, unit time.Duration) []byte {
+	var out []byte
+	abs := uint64(d)
+	if d < 0 {
+		out = append(out, '-')
+		abs = -abs
+	}
+
+	out = strconv.AppendUint(out, abs/uint64(unit), 10)
+	rest := abs % uint64(unit)
+	if rest == 0 {
+		return out
+	}
+
+	width := len(strconv.FormatUint(uint64(unit), 10)) - 1
+	frac := strconv.FormatUint(rest, 10)
+	frac = strings.Repeat("0", width-len(frac)) + frac
+	out = append(out, '.')
+	return append(out, strings.TrimRight(frac, "0")...)
+}
@@ -0,0 +1,131 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestDurationJSON(t *testing.T) {
+	var v struct {
+		Timeout  Duration
+		Interval Seconds
+		Delay    Millis
+	}
+
+	err := json.Unmarshal([]byte(`{"Timeout":"1m30s","Interval":1.5,"Delay":250}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if time.Duration(v.Timeout) != 90*time.Second {
+		t.Errorf("wrong timeout: %s", v.Timeout)
+	}
+	if time.Duration(v.Interval) != 1500*time.Millisecond {
+		t.Errorf("wrong interval: %s", v.Interval)
+	}
+	if time.Duration(v.Delay) != 250*time.Millisecond {
+		t.Errorf("wrong delay: %s", v.Delay)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"Timeout":"1m30s","Interval":1.5,"Delay":250}` {
+		t.Errorf("wrong output: %s", out)
+	}
+}
+
+func TestDurationFormat(t *testing.T) {
+	tcases := []struct {
+		d    time.Duration
+		secs string
+		ms   string
+	}{
+		{0, "0", "0"},
+		{time.Second, "1", "1000"},
+		{-1500 * time.Millisecond, "-1.5", "-1500"},
+		{time.Nanosecond, "0.000000001", "0.000001"},
+		{90*time.Second + 250*time.Microsecond, "90.00025", "90000.25"},
+		{-1 << 63, "-9223372036.854775808", "-9223372036854.775808"},
+	}
+
+	for _, tc := range tcases {
+		if got := Seconds(tc.d).String(); got != tc.secs {
+			t.Errorf("%d: Seconds %s, want %s", tc.d, got, tc.secs)
+		}
+		if got := Millis(tc.d).String(); got != tc.ms {
+			t.Errorf("%d: Millis %s, want %s", tc.d, got, tc.ms)
+		}
+	}
+}
+
+func TestDurationRoundtrip(t *testing.T) {
+	f := func(n int64) bool {
+		for _, v := range []interface {
+			json.Marshaler
+		}{Seconds(n), Millis(n), Duration(n)} {
+			out, err := v.MarshalJSON()
+			if err != nil {
+				t.Log(err)
+				return false
+			}
+
+			var got int64
+			switch v.(type) {
+			case Seconds:
+				var d Seconds
+				err = json.Unmarshal(out, &d)
+				got = int64(d)
+			case Millis:
+				var d Millis
+				err = json.Unmarshal(out, &d)
+				got = int64(d)
+			case Duration:
+				var d Duration
+				err = json.Unmarshal(out, &d)
+				got = int64(d)
+			}
+			if err != nil || got != n {
+				t.Logf("%T(%d): got %d (%s): %v", v, n, got, out, err)
+				return false
+			}
+		}
+		return true
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDurationInvalid(t *testing.T) {
+	for _, in := range []string{`"1.5"`, `"soon"`, `1e30`, `true`} {
+		var s Seconds
+		if err := json.Unmarshal([]byte(in), &s); err == nil {
+			t.Errorf("Seconds %s: expected error", in)
+		}
+	}
+
+	for _, in := range []string{`90`, `"soon"`, `""`} {
+		var d Duration
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("Duration %s: expected error", in)
+		}
+	}
+}
+
+func TestDurationLenient(t *testing.T) {
+	var v struct {
+		Timeout  LenientDuration
+		Interval LenientSeconds
+	}
+	err := json.Unmarshal([]byte(`{"Timeout":"PT1M30S","Interval":"1.5"}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Duration(v.Timeout.Duration) != 90*time.Second || time.Duration(v.Interval.Seconds) != 1500*time.Millisecond {
+		t.Errorf("wrong values: %+v", v)
+	}
+}
@@ -0,0 +1,219 @@
+package encodedTime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Formatted marshals Time with a fixed Layout in a fixed Location.
+// The zero Layout means time.RFC3339 and a nil Location keeps the zone of Time.
+//
+// Since the layout is part of the value, create it with NewFormatted (or set Layout and Location)
+// before unmarshaling into it. Parsed times are converted into Location.
+type Formatted struct {
+	Time     time.Time
+	Layout   string
+	Location *time.Location
+}
+
+// NewFormatted returns a Formatted that uses layout and loc for t
+func NewFormatted(t time.Time, layout string, loc *time.Location) Formatted {
+	return Formatted{Time: t, Layout: layout, Location: loc}
+}
+
+func (t Formatted) layout() string {
+	if t.Layout == "" {
+		return time.RFC3339
+	}
+	return t.Layout
+}
+
+// String formats Time with Layout in Location, which makes *Formatted a flag.Value together with Set
+func (t Formatted) String() string {
+	tv := t.Time
+	if t.Location != nil {
+		tv = tv.In(t.Location)
+	}
+	return tv.Format(t.layout())
+}
+
+// Set implements flag.Value
+func (t *Formatted) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// MarshalText returns the output of String()
+func (t Formatted) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText parses the text with Layout. Layouts without a zone are read in Location (or UTC).
+func (t *Formatted) UnmarshalText(in []byte) error {
+	loc := t.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+
+	tv, err := time.ParseInLocation(t.layout(), strings.TrimSpace(string(in)), loc)
+	if err != nil {
+		return fmt.Errorf("encodedTime: %w", err)
+	}
+	if t.Location != nil {
+		tv = tv.In(t.Location)
+	}
+
+	t.Time = tv
+	return nil
+}
+
+// MarshalJSON returns the quoted output of String()
+func (t Formatted) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON parses a string like UnmarshalText. null is a no-op.
+func (t *Formatted) UnmarshalJSON(in []byte) error {
+	s, null, err := decodeString(in)
+	if err != nil || null {
+		return err
+	}
+	return t.UnmarshalText([]byte(s))
+}
+
+// dateLayout is the ISO 8601 calendar date
+const dateLayout = "2006-01-02"
+
+// Date encodes the calendar date of a time as YYYY-MM-DD.
+// Decoded dates are at midnight UTC.
+type Date time.Time
+
+// NewDate returns the date at midnight UTC
+func NewDate(year int, month time.Month, day int) Date {
+	return Date(time.Date(year, month, day, 0, 0, 0, 0, time.UTC))
+}
+
+// String returns the date as YYYY-MM-DD in the zone of the time, which makes *Date a flag.Value together with Set
+func (d Date) String() string {
+	return time.Time(d).Format(dateLayout)
+}
+
+// Set implements flag.Value
+func (d *Date) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+// MarshalText returns the output of String()
+func (d Date) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText parses YYYY-MM-DD
+func (d *Date) UnmarshalText(in []byte) error {
+	tv, err := time.Parse(dateLayout, strings.TrimSpace(string(in)))
+	if err != nil {
+		return fmt.Errorf("encodedTime: invalid date: %w", err)
+	}
+
+	*d = Date(tv)
+	return nil
+}
+
+// MarshalJSON returns the quoted output of String()
+func (d Date) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON parses a YYYY-MM-DD string. null is a no-op.
+func (d *Date) UnmarshalJSON(in []byte) error {
+	s, null, err := decodeString(in)
+	if err != nil || null {
+		return err
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// TimeOfDay is a wall clock time without a date or zone. It encodes as HH:MM:SS,
+// with a fraction if Nanosecond isn't zero.
+type TimeOfDay struct {
+	Hour, Minute, Second, Nanosecond int
+}
+
+// TimeOfDayOf returns the wall clock time of t in its zone
+func TimeOfDayOf(t time.Time) TimeOfDay {
+	return TimeOfDay{Hour: t.Hour(), Minute: t.Minute(), Second: t.Second(), Nanosecond: t.Nanosecond()}
+}
+
+// On returns the time of day on the date of d in loc
+func (tod TimeOfDay) On(d Date, loc *time.Location) time.Time {
+	y, m, day := time.Time(d).Date()
+	return time.Date(y, m, day, tod.Hour, tod.Minute, tod.Second, tod.Nanosecond, loc)
+}
+
+// String returns HH:MM:SS[.fraction], which makes *TimeOfDay a flag.Value together with Set
+func (tod TimeOfDay) String() string {
+	return time.Date(0, 1, 1, tod.Hour, tod.Minute, tod.Second, tod.Nanosecond, time.UTC).Format("15:04:05.999999999")
+}
+
+// Set implements flag.Value
+func (tod *TimeOfDay) Set(s string) error {
+	return tod.UnmarshalText([]byte(s))
+}
+
+// MarshalText returns the output of String()
+func (tod TimeOfDay) MarshalText() ([]byte, error) {
+	if tod.Hour < 0 || tod.Hour > 23 || tod.Minute < 0 || tod.Minute > 59 ||
+		tod.Second < 0 || tod.Second > 59 || tod.Nanosecond < 0 || tod.Nanosecond > 999999999 {
+		return nil, fmt.Errorf("encodedTime: invalid time of day: %+v", tod)
+	}
+	return []byte(tod.String()), nil
+}
+
+// UnmarshalText parses HH:MM:SS with an optional fraction or HH:MM
+func (tod *TimeOfDay) UnmarshalText(in []byte) error {
+	s := strings.TrimSpace(string(in))
+	tv, err := time.Parse("15:04:05", s) // also accepts a fraction
+	if err != nil {
+		var err2 error
+		if tv, err2 = time.Parse("15:04", s); err2 != nil {
+			return fmt.Errorf("encodedTime: invalid time of day: %w", err)
+		}
+	}
+
+	*tod = TimeOfDayOf(tv)
+	return nil
+}
+
+// MarshalJSON returns the quoted output of MarshalText()
+func (tod TimeOfDay) MarshalJSON() ([]byte, error) {
+	out, err := tod.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(string(out))
+}
+
+// UnmarshalJSON parses a string like UnmarshalText. null is a no-op.
+func (tod *TimeOfDay) UnmarshalJSON(in []byte) error {
+	s, null, err := decodeString(in)
+	if err != nil || null {
+		return err
+	}
+	return tod.UnmarshalText([]byte(s))
+}
+
+// decodeString unquotes a JSON string. null is true for a JSON null.
+func decodeString(in []byte) (string, bool, error) {
+	in = bytes.TrimSpace(in)
+	if bytes.Equal(in, jsonNull) {
+		return "", true, nil
+	}
+
+	var s string
+	if err := json.Unmarshal(in, &s); err != nil {
+		return "", false, fmt.Errorf("encodedTime: %q is not a string: %w", in, err)
+	}
+	return s, false, nil
+}
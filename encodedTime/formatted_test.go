@@ -0,0 +1,126 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestFormatted(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Skip("no zoneinfo:", err)
+	}
+
+	f := NewFormatted(time.Unix(1600000000, 0), time.RFC1123, berlin)
+	out, err := json.Marshal(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `"Sun, 13 Sep 2020 14:26:40 CEST"` {
+		t.Fatalf("wrong output: %s", out)
+	}
+
+	back := NewFormatted(time.Time{}, time.RFC1123, berlin)
+	if err := json.Unmarshal(out, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !back.Time.Equal(f.Time) {
+		t.Fatalf("wrong time: %s", back.Time)
+	}
+
+	// without a zone in the layout the time is read in the location
+	local := NewFormatted(time.Time{}, "2006-01-02 15:04", berlin)
+	if err := json.Unmarshal([]byte(`"2020-09-13 14:26"`), &local); err != nil {
+		t.Fatal(err)
+	}
+	if !local.Time.Equal(time.Unix(1600000000-40, 0)) {
+		t.Fatalf("wrong time: %s", local.Time)
+	}
+
+	var def Formatted
+	if err := json.Unmarshal([]byte(`"2020-09-13T12:26:40Z"`), &def); err != nil {
+		t.Fatal(err)
+	}
+	if !def.Time.Equal(f.Time) {
+		t.Fatalf("wrong time: %s", def.Time)
+	}
+
+	if err := json.Unmarshal([]byte(`"yesterday"`), &def); err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestDate(t *testing.T) {
+	var v struct {
+		Birthday Date
+	}
+
+	if err := json.Unmarshal([]byte(`{"Birthday":"2020-02-29"}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if !time.Time(v.Birthday).Equal(time.Time(NewDate(2020, time.February, 29))) {
+		t.Fatalf("wrong date: %s", v.Birthday)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"Birthday":"2020-02-29"}` {
+		t.Fatalf("wrong output: %s", out)
+	}
+
+	for _, in := range []string{`"2019-02-29"`, `"2020-2-3"`, `20200229`, `"2020-02-29T00:00:00Z"`} {
+		var d Date
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+	}
+}
+
+func TestTimeOfDay(t *testing.T) {
+	tcases := []struct {
+		in   string
+		want TimeOfDay
+		out  string
+	}{
+		{`"08:30:00"`, TimeOfDay{8, 30, 0, 0}, `"08:30:00"`},
+		{`"23:59"`, TimeOfDay{23, 59, 0, 0}, `"23:59:00"`},
+		{`"12:00:01.25"`, TimeOfDay{12, 0, 1, 250000000}, `"12:00:01.25"`},
+	}
+
+	for _, tc := range tcases {
+		var tod TimeOfDay
+		if err := json.Unmarshal([]byte(tc.in), &tod); err != nil {
+			t.Errorf("%s: %s", tc.in, err)
+			continue
+		}
+		if tod != tc.want {
+			t.Errorf("%s: got %+v", tc.in, tod)
+		}
+		out, err := json.Marshal(tod)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != tc.out {
+			t.Errorf("%s: got %s", tc.in, out)
+		}
+	}
+
+	for _, in := range []string{`"24:00:00"`, `"08:60"`, `"noon"`} {
+		var tod TimeOfDay
+		if err := json.Unmarshal([]byte(in), &tod); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+	}
+
+	if _, err := json.Marshal(TimeOfDay{Hour: 25}); err == nil {
+		t.Error("expected error for invalid hour")
+	}
+
+	at := TimeOfDay{Hour: 12, Minute: 26, Second: 40}.On(NewDate(2020, time.September, 13), time.UTC)
+	if !at.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("wrong time: %s", at)
+	}
+}
@@ -0,0 +1,178 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ISODuration encodes a time.Duration as an ISO 8601 duration string like "PT1H30M".
+//
+// Years and months have no fixed length and are rejected, weeks and days count as 7*24 and 24 hours.
+// Fractions are allowed on every component. Marshaling only uses hours, minutes and seconds.
+type ISODuration time.Duration
+
+// UnmarshalJSON parses an ISO 8601 duration string. null is a no-op.
+func (d *ISODuration) UnmarshalJSON(in []byte) error {
+	s, null, err := decodeString(in)
+	if err != nil || null {
+		return err
+	}
+	return d.UnmarshalText([]byte(s))
+}
+
+// MarshalJSON returns the quoted output of String()
+func (d ISODuration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalText parses an ISO 8601 duration
+func (d *ISODuration) UnmarshalText(in []byte) error {
+	v, err := parseISODuration(strings.TrimSpace(string(in)))
+	if err != nil {
+		return err
+	}
+
+	*d = ISODuration(v)
+	return nil
+}
+
+// MarshalText returns the output of String()
+func (d ISODuration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// String formats the duration in hours, minutes and seconds, like "PT1H30M" or "-PT0.5S".
+// Together with Set this makes *ISODuration a flag.Value.
+func (d ISODuration) String() string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	abs := uint64(d)
+	if d < 0 {
+		b.WriteByte('-')
+		abs = -abs
+	}
+	b.WriteString("PT")
+
+	if h := abs / uint64(time.Hour); h > 0 {
+		b.WriteString(strconv.FormatUint(h, 10))
+		b.WriteByte('H')
+	}
+	if m := abs % uint64(time.Hour) / uint64(time.Minute); m > 0 {
+		b.WriteString(strconv.FormatUint(m, 10))
+		b.WriteByte('M')
+	}
+	if s := abs % uint64(time.Minute); s > 0 {
+		b.Write(formatDuration(time.Duration(s), time.Second))
+		b.WriteByte('S')
+	}
+	return b.String()
+}
+
+// Set implements flag.Value
+func (d *ISODuration) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+// isoDesignators lists the allowed components in their required order
+var isoDesignators = []struct {
+	date   bool
+	symbol byte
+	unit   time.Duration
+}{
+	{true, 'Y', 0},
+	{true, 'M', 0},
+	{true, 'W', 7 * 24 * time.Hour},
+	{true, 'D', 24 * time.Hour},
+	{false, 'H', time.Hour},
+	{false, 'M', time.Minute},
+	{false, 'S', time.Second},
+}
+
+// parseISODuration parses the duration part of ISO 8601, see ISODuration for the restrictions
+func parseISODuration(s string) (time.Duration, error) {
+	in := s
+	neg := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		neg = s[0] == '-'
+		s = s[1:]
+	}
+	if !strings.HasPrefix(s, "P") || len(s) == 1 {
+		return 0, fmt.Errorf("encodedTime: invalid ISO 8601 duration %q", in)
+	}
+	s = s[1:]
+
+	var (
+		total      = new(big.Rat)
+		inTime     = false
+		next       = 0 // index into isoDesignators
+		components = 0
+	)
+	for len(s) > 0 {
+		if s[0] == 'T' {
+			if inTime {
+				return 0, fmt.Errorf("encodedTime: invalid ISO 8601 duration %q", in)
+			}
+			inTime = true
+			s = s[1:]
+			if len(s) == 0 {
+				return 0, fmt.Errorf("encodedTime: invalid ISO 8601 duration %q: nothing after T", in)
+			}
+			continue
+		}
+
+		i := strings.IndexFunc(s, func(r rune) bool {
+			return (r < '0' || r > '9') && r != '.' && r != ','
+		})
+		if i <= 0 {
+			return 0, fmt.Errorf("encodedTime: invalid ISO 8601 duration %q", in)
+		}
+		num, symbol := strings.Replace(s[:i], ",", ".", 1), s[i]
+		s = s[i+1:]
+
+		// find the designator, respecting the order and the date/time split
+		found := -1
+		for j := next; j < len(isoDesignators); j++ {
+			if isoDesignators[j].date != inTime && isoDesignators[j].symbol == symbol {
+				found = j
+				break
+			}
+		}
+		if found == -1 {
+			return 0, fmt.Errorf("encodedTime: invalid ISO 8601 duration %q: unexpected %q", in, symbol)
+		}
+		next = found + 1
+		components++
+
+		r, ok := new(big.Rat).SetString(num)
+		if !ok || strings.HasPrefix(num, ".") || strings.HasSuffix(num, ".") {
+			return 0, fmt.Errorf("encodedTime: invalid ISO 8601 duration %q: bad number %q", in, num)
+		}
+		des := isoDesignators[found]
+		if des.unit == 0 {
+			if r.Sign() != 0 {
+				return 0, fmt.Errorf("encodedTime: ISO 8601 duration %q uses years or months which have no fixed length", in)
+			}
+			continue
+		}
+		total.Add(total, r.Mul(r, new(big.Rat).SetInt64(int64(des.unit))))
+	}
+	if components == 0 {
+		return 0, fmt.Errorf("encodedTime: invalid ISO 8601 duration %q", in)
+	}
+
+	if neg {
+		total.Neg(total)
+	}
+	ns := new(big.Int).Quo(total.Num(), total.Denom())
+	if ns.Cmp(minDuration) < 0 || ns.Cmp(maxDuration) > 0 {
+		return 0, fmt.Errorf("encodedTime: ISO 8601 duration %q out of range", in)
+	}
+	return time.Duration(ns.Int64()), nil
+}
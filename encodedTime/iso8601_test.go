@@ -0,0 +1,87 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestISODurationParse(t *testing.T) {
+	tcases := []struct {
+		in   string
+		want time.Duration
+	}{
+		{"PT1H30M", 90 * time.Minute},
+		{"PT0S", 0},
+		{"P1D", 24 * time.Hour},
+		{"P1W", 7 * 24 * time.Hour},
+		{"P1DT12H", 36 * time.Hour},
+		{"PT0.5S", 500 * time.Millisecond},
+		{"PT1,5M", 90 * time.Second},
+		{"-PT10S", -10 * time.Second},
+		{"P0Y0M1DT0S", 24 * time.Hour},
+		{"PT36H", 36 * time.Hour},
+	}
+
+	for _, tc := range tcases {
+		var d ISODuration
+		if err := d.UnmarshalText([]byte(tc.in)); err != nil {
+			t.Errorf("%s: %s", tc.in, err)
+			continue
+		}
+		if time.Duration(d) != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.in, time.Duration(d), tc.want)
+		}
+	}
+}
+
+func TestISODurationInvalid(t *testing.T) {
+	for _, in := range []string{"", "P", "PT", "1H", "PT1D", "P1H", "P1Y", "P2M", "PT1S1M", "PT.5S", "PT1.S", "PTT1S", "P1DT", "PT1.2.3S", "P99999999999W"} {
+		var d ISODuration
+		if err := d.UnmarshalText([]byte(in)); err == nil {
+			t.Errorf("%q: expected error, got %s", in, time.Duration(d))
+		}
+	}
+}
+
+func TestISODurationFormat(t *testing.T) {
+	tcases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "PT0S"},
+		{90 * time.Minute, "PT1H30M"},
+		{36 * time.Hour, "PT36H"},
+		{-500 * time.Millisecond, "-PT0.5S"},
+		{time.Hour + time.Second + time.Nanosecond, "PT1H1.000000001S"},
+	}
+
+	for _, tc := range tcases {
+		out, err := json.Marshal(ISODuration(tc.d))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != `"`+tc.want+`"` {
+			t.Errorf("%s: got %s, want %s", tc.d, out, tc.want)
+		}
+	}
+}
+
+func TestISODurationRoundtrip(t *testing.T) {
+	f := func(n int64) bool {
+		out, err := ISODuration(n).MarshalText()
+		if err != nil {
+			return false
+		}
+		var d ISODuration
+		if err := d.UnmarshalText(out); err != nil {
+			t.Logf("%d (%s): %s", n, out, err)
+			return false
+		}
+		return int64(d) == n
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+}
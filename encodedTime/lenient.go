@@ -0,0 +1,391 @@
+package encodedTime
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// The Lenient types embed the type of the same name and only differ in decoding,
+// which is lenient for JSON, text, YAML and flag.Value alike.
+// Besides the inputs of the embedded type, they also accept quoted numbers ("1600000000"),
+// fractions for Unix, RFC3339 strings and empty strings (treated like null).
+// Marshalling always produces the canonical form of the embedded type.
+
+// LenientUnix is a Unix with lenient decoding
+type LenientUnix struct{ Unix }
+
+// UnmarshalJSON decodes like Unix.UnmarshalJSON and accepts the lenient inputs, too
+func (t *LenientUnix) UnmarshalJSON(in []byte) error {
+	tv, null, err := decodeJSON(in, time.Second, true, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Unix = Unix(tv)
+	return nil
+}
+
+// UnmarshalText decodes the text like a JSON string, an empty text is a no-op
+func (t *LenientUnix) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Second, true, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Unix = Unix(tv)
+	return nil
+}
+
+// Set implements flag.Value and decodes like UnmarshalText
+func (t *LenientUnix) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// UnmarshalYAML decodes YAML numbers and strings like UnmarshalText
+func (t *LenientUnix) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Second, true, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Unix = Unix(tv)
+	return nil
+}
+
+// LenientMillisecs is a Millisecs with lenient decoding
+type LenientMillisecs struct{ Millisecs }
+
+// UnmarshalJSON decodes like Millisecs.UnmarshalJSON and accepts the lenient inputs, too
+func (t *LenientMillisecs) UnmarshalJSON(in []byte) error {
+	tv, null, err := decodeJSON(in, time.Millisecond, false, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Millisecs = Millisecs(tv)
+	return nil
+}
+
+// UnmarshalText decodes the text like a JSON string, an empty text is a no-op
+func (t *LenientMillisecs) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Millisecond, false, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Millisecs = Millisecs(tv)
+	return nil
+}
+
+// Set implements flag.Value and decodes like UnmarshalText
+func (t *LenientMillisecs) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// UnmarshalYAML decodes YAML numbers and strings like UnmarshalText
+func (t *LenientMillisecs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Millisecond, false, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Millisecs = Millisecs(tv)
+	return nil
+}
+
+// LenientMicrosecs is a Microsecs with lenient decoding
+type LenientMicrosecs struct{ Microsecs }
+
+// UnmarshalJSON decodes like Microsecs.UnmarshalJSON and accepts the lenient inputs, too
+func (t *LenientMicrosecs) UnmarshalJSON(in []byte) error {
+	tv, null, err := decodeJSON(in, time.Microsecond, false, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Microsecs = Microsecs(tv)
+	return nil
+}
+
+// UnmarshalText decodes the text like a JSON string, an empty text is a no-op
+func (t *LenientMicrosecs) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Microsecond, false, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Microsecs = Microsecs(tv)
+	return nil
+}
+
+// Set implements flag.Value and decodes like UnmarshalText
+func (t *LenientMicrosecs) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// UnmarshalYAML decodes YAML numbers and strings like UnmarshalText
+func (t *LenientMicrosecs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Microsecond, false, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Microsecs = Microsecs(tv)
+	return nil
+}
+
+// LenientNanosecs is a Nanosecs with lenient decoding
+type LenientNanosecs struct{ Nanosecs }
+
+// UnmarshalJSON decodes like Nanosecs.UnmarshalJSON and accepts the lenient inputs, too
+func (t *LenientNanosecs) UnmarshalJSON(in []byte) error {
+	tv, null, err := decodeJSON(in, time.Nanosecond, false, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Nanosecs = Nanosecs(tv)
+	return nil
+}
+
+// UnmarshalText decodes the text like a JSON string, an empty text is a no-op
+func (t *LenientNanosecs) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Nanosecond, false, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Nanosecs = Nanosecs(tv)
+	return nil
+}
+
+// Set implements flag.Value and decodes like UnmarshalText
+func (t *LenientNanosecs) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// UnmarshalYAML decodes YAML numbers and strings like UnmarshalText
+func (t *LenientNanosecs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Nanosecond, false, true)
+	if err != nil || null {
+		return err
+	}
+
+	t.Nanosecs = Nanosecs(tv)
+	return nil
+}
+
+// LenientNullUnix is a NullUnix with lenient decoding
+type LenientNullUnix struct{ NullUnix }
+
+// UnmarshalJSON sets Valid to false for null and empty strings, otherwise it decodes like LenientUnix
+func (t *LenientNullUnix) UnmarshalJSON(in []byte) error {
+	tv, null, err := decodeJSON(in, time.Second, true, true)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// UnmarshalText sets Valid to false for empty texts, otherwise it decodes like LenientUnix
+func (t *LenientNullUnix) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Second, true, true)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// Set implements flag.Value and decodes like UnmarshalText
+func (t *LenientNullUnix) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// UnmarshalYAML sets Valid to false for null and empty strings, otherwise it decodes like LenientUnix
+func (t *LenientNullUnix) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Second, true, true)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// LenientNullMillisecs is a NullMillisecs with lenient decoding
+type LenientNullMillisecs struct{ NullMillisecs }
+
+// UnmarshalJSON sets Valid to false for null and empty strings, otherwise it decodes like LenientMillisecs
+func (t *LenientNullMillisecs) UnmarshalJSON(in []byte) error {
+	tv, null, err := decodeJSON(in, time.Millisecond, false, true)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// UnmarshalText sets Valid to false for empty texts, otherwise it decodes like LenientMillisecs
+func (t *LenientNullMillisecs) UnmarshalText(in []byte) error {
+	tv, null, err := decodeText(in, time.Millisecond, false, true)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// Set implements flag.Value and decodes like UnmarshalText
+func (t *LenientNullMillisecs) Set(s string) error {
+	return t.UnmarshalText([]byte(s))
+}
+
+// UnmarshalYAML sets Valid to false for null and empty strings, otherwise it decodes like LenientMillisecs
+func (t *LenientNullMillisecs) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tv, null, err := decodeYAML(unmarshal, time.Millisecond, false, true)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// LenientDuration is a Duration that also accepts ISO 8601 durations and empty strings (a no-op)
+type LenientDuration struct{ Duration }
+
+// UnmarshalJSON decodes like Duration.UnmarshalJSON and accepts the lenient inputs, too
+func (d *LenientDuration) UnmarshalJSON(in []byte) error {
+	v, null, err := decodeGoDurationJSON(in, true)
+	if err != nil || null {
+		return err
+	}
+
+	d.Duration = Duration(v)
+	return nil
+}
+
+// UnmarshalText decodes like UnmarshalJSON without the quotes
+func (d *LenientDuration) UnmarshalText(in []byte) error {
+	v, null, err := parseGoDuration(string(in), true)
+	if err != nil || null {
+		return err
+	}
+
+	d.Duration = Duration(v)
+	return nil
+}
+
+// Set implements flag.Value and decodes like UnmarshalText
+func (d *LenientDuration) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+// LenientSeconds is a Seconds that also accepts quoted numbers and empty strings (a no-op)
+type LenientSeconds struct{ Seconds }
+
+// UnmarshalJSON decodes like Seconds.UnmarshalJSON and accepts the lenient inputs, too
+func (d *LenientSeconds) UnmarshalJSON(in []byte) error {
+	v, null, err := decodeDurationJSON(in, time.Second, true)
+	if err != nil || null {
+		return err
+	}
+
+	d.Seconds = Seconds(v)
+	return nil
+}
+
+// UnmarshalText treats the text like a JSON string, an empty text is a no-op
+func (d *LenientSeconds) UnmarshalText(in []byte) error {
+	quoted, err := json.Marshal(strings.TrimSpace(string(in)))
+	if err != nil {
+		return err
+	}
+	return d.UnmarshalJSON(quoted)
+}
+
+// Set implements flag.Value and decodes like UnmarshalText
+func (d *LenientSeconds) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+// LenientMillis is a Millis that also accepts quoted numbers and empty strings (a no-op)
+type LenientMillis struct{ Millis }
+
+// UnmarshalJSON decodes like Millis.UnmarshalJSON and accepts the lenient inputs, too
+func (d *LenientMillis) UnmarshalJSON(in []byte) error {
+	v, null, err := decodeDurationJSON(in, time.Millisecond, true)
+	if err != nil || null {
+		return err
+	}
+
+	d.Millis = Millis(v)
+	return nil
+}
+
+// UnmarshalText treats the text like a JSON string, an empty text is a no-op
+func (d *LenientMillis) UnmarshalText(in []byte) error {
+	quoted, err := json.Marshal(strings.TrimSpace(string(in)))
+	if err != nil {
+		return err
+	}
+	return d.UnmarshalJSON(quoted)
+}
+
+// Set implements flag.Value and decodes like UnmarshalText
+func (d *LenientMillis) Set(s string) error {
+	return d.UnmarshalText([]byte(s))
+}
+
+var jsonNull = []byte("null")
+
+// decodeJSON is the shared UnmarshalJSON implementation of the numeric types.
+// null is true for a JSON null or, if lenient is set, an empty string.
+// If integral is set, fractions and exponents are only accepted if lenient is set, too.
+func decodeJSON(in []byte, unit time.Duration, integral, lenient bool) (t time.Time, null bool, err error) {
+	in = bytes.TrimSpace(in)
+	if bytes.Equal(in, jsonNull) {
+		return time.Time{}, true, nil
+	}
+
+	if !lenient {
+		if integral && bytes.IndexAny(in, ".eE") != -1 {
+			return time.Time{}, false, fmt.Errorf("encodedTime: expected an integer, got %q", in)
+		}
+		t, err = parseUnits(in, unit)
+		return t, false, err
+	}
+
+	if len(in) == 0 || in[0] != '"' {
+		t, err = parseUnits(in, unit)
+		return t, false, err
+	}
+
+	var s string
+	if err := json.Unmarshal(in, &s); err != nil {
+		return time.Time{}, false, fmt.Errorf("encodedTime: invalid string %q: %w", in, err)
+	}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, true, nil
+	}
+
+	if t, err = parseUnits([]byte(s), unit); err == nil {
+		return t, false, nil
+	}
+
+	t, err = time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("encodedTime: %q is neither a number nor a RFC3339 time", s)
+	}
+	return t, false, nil
+}
@@ -0,0 +1,142 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestStrictRejects(t *testing.T) {
+	for _, in := range []string{`"1600000000"`, `""`, `1600000000.5`, `"2020-09-13T12:26:40Z"`} {
+		var u Unix
+		if err := json.Unmarshal([]byte(in), &u); err == nil {
+			t.Errorf("%s: expected error in strict mode", in)
+		}
+	}
+
+	// null is a no-op like for the builtin types
+	u := NewUnix(42)
+	if err := json.Unmarshal([]byte(`null`), &u); err != nil {
+		t.Fatal(err)
+	}
+	if !time.Time(u).Equal(time.Unix(42, 0)) {
+		t.Fatal("null changed the value")
+	}
+}
+
+func TestLenient(t *testing.T) {
+	want := time.Unix(1600000000, 0)
+	for _, in := range []string{`1600000000`, `"1600000000"`, `" 1600000000 "`, `1.6e9`, `"2020-09-13T12:26:40Z"`, `"2020-09-13T14:26:40+02:00"`} {
+		var u LenientUnix
+		if err := json.Unmarshal([]byte(in), &u); err != nil {
+			t.Errorf("%s: %s", in, err)
+			continue
+		}
+		if !time.Time(u.Unix).Equal(want) {
+			t.Errorf("%s: got %s", in, time.Time(u.Unix))
+		}
+
+		out, err := json.Marshal(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(out) != "1600000000" {
+			t.Errorf("%s: not canonical: %s", in, out)
+		}
+	}
+
+	var ms LenientMillisecs
+	if err := json.Unmarshal([]byte(`"1600000000500.5"`), &ms); err != nil {
+		t.Fatal(err)
+	}
+	if !time.Time(ms.Millisecs).Equal(time.Unix(1600000000, 500500000)) {
+		t.Errorf("got %s", time.Time(ms.Millisecs))
+	}
+
+	var v struct {
+		A LenientNullUnix
+		B LenientNullMillisecs
+		C Unix
+	}
+	if err := json.Unmarshal([]byte(`{"A":"","B":"1600000000500","C":1600000000}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.A.Valid {
+		t.Error("empty string should be invalid")
+	}
+	if !v.B.Valid || !v.B.Time.Equal(time.Unix(1600000000, 500*int64(time.Millisecond))) {
+		t.Errorf("wrong B: %+v", v.B)
+	}
+
+	// the lenient types don't change the strict ones
+	if err := json.Unmarshal([]byte(`{"C":"1600000000"}`), &v); err == nil {
+		t.Error("strict field accepted a quoted number")
+	}
+
+	var txt LenientUnix
+	if err := txt.UnmarshalText([]byte("2020-09-13T12:26:40Z")); err != nil || !time.Time(txt.Unix).Equal(want) {
+		t.Errorf("text: %v %s", err, time.Time(txt.Unix))
+	}
+
+	for _, in := range []string{`"yesterday"`, `"0x10"`, `true`} {
+		var u LenientUnix
+		if err := json.Unmarshal([]byte(in), &u); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+	}
+}
+
+func TestLenientSetAndYAML(t *testing.T) {
+	want := time.Unix(1600000000, 0)
+	var (
+		u  LenientUnix
+		ms LenientMillisecs
+		us LenientMicrosecs
+		ns LenientNanosecs
+		nu LenientNullUnix
+		nm LenientNullMillisecs
+	)
+	type decoder interface {
+		Set(string) error
+		UnmarshalYAML(func(interface{}) error) error
+	}
+	tcases := []struct {
+		v   decoder
+		get func() time.Time
+	}{
+		{&u, func() time.Time { return time.Time(u.Unix) }},
+		{&ms, func() time.Time { return time.Time(ms.Millisecs) }},
+		{&us, func() time.Time { return time.Time(us.Microsecs) }},
+		{&ns, func() time.Time { return time.Time(ns.Nanosecs) }},
+		{&nu, func() time.Time { return nu.Time }},
+		{&nm, func() time.Time { return nm.Time }},
+	}
+	for i, tc := range tcases {
+		if err := tc.v.Set("2020-09-13T12:26:40Z"); err != nil || !tc.get().Equal(want) {
+			t.Errorf("%d: Set: %v %s", i, err, tc.get())
+		}
+		if err := tc.v.UnmarshalYAML(yamlValue("2020-09-13T12:26:40Z")); err != nil || !tc.get().Equal(want) {
+			t.Errorf("%d: YAML: %v %s", i, err, tc.get())
+		}
+	}
+
+	if err := u.UnmarshalYAML(yamlValue(1.6e9)); err != nil || !time.Time(u.Unix).Equal(want) {
+		t.Errorf("YAML float: %v %s", err, time.Time(u.Unix))
+	}
+	if err := nu.UnmarshalYAML(yamlValue("")); err != nil || nu.Valid {
+		t.Errorf("YAML empty string: %v %+v", err, nu)
+	}
+
+	var d LenientDuration
+	if err := d.Set("PT1M30S"); err != nil || time.Duration(d.Duration) != 90*time.Second {
+		t.Errorf("Duration Set: %v %s", err, d)
+	}
+	var s LenientSeconds
+	if err := s.Set("1.5"); err != nil || time.Duration(s.Seconds) != 1500*time.Millisecond {
+		t.Errorf("Seconds Set: %v %s", err, s)
+	}
+	var m LenientMillis
+	if err := m.Set(" 1500 "); err != nil || time.Duration(m.Millis) != 1500*time.Millisecond {
+		t.Errorf("Millis Set: %v %s", err, m)
+	}
+}
@@ -0,0 +1,48 @@
+package encodedTime
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// Microsecs is used to get a time from a number that represents a timestamp in microseconds
+type Microsecs time.Time
+
+// NewMicrosecs returns a Microsecs instance with usecs since unix-0
+func NewMicrosecs(usecs int64) Microsecs {
+	return Microsecs(fromUnits(usecs, time.Microsecond))
+}
+
+// UnmarshalJSON for Microsecs accepts integers, fractions and exponents.
+// Sub-microsecond fractions are kept down to the nanosecond.
+func (t *Microsecs) UnmarshalJSON(in []byte) (err error) {
+	tv, null, err := decodeJSON(in, time.Microsecond, false, false)
+	if err != nil || null {
+		return err
+	}
+
+	*t = Microsecs(tv)
+	return nil
+}
+
+// MarshalJSON returns the whole microseconds since unix-0, anything finer is floored
+func (t Microsecs) MarshalJSON() ([]byte, error) {
+	return marshalUnits(time.Time(t), time.Microsecond)
+}
+
+// Scan implements sql.Scanner. Integers, floats and numeric strings are read as microseconds since unix-0,
+// time.Time values and textual timestamps are taken as they are.
+func (t *Microsecs) Scan(src interface{}) error {
+	tv, err := scanTime(src, time.Microsecond)
+	if err != nil {
+		return err
+	}
+
+	*t = Microsecs(tv)
+	return nil
+}
+
+// Value implements driver.Valuer and stores the whole microseconds since unix-0 as an integer
+func (t Microsecs) Value() (driver.Value, error) {
+	return formatUnits(time.Time(t), time.Microsecond)
+}
@@ -0,0 +1,73 @@
+package encodedTime
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestMicrosecsUnmarshall(t *testing.T) {
+	v := struct {
+		Timestamp Microsecs
+	}{}
+
+	err := json.Unmarshal([]byte(`{"Timestamp":1449808143436123.5}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := time.Time(v.Timestamp).Sub(time.Unix(1449808143, 436123500)); n != 0 {
+		t.Fatalf("times not equal:%d", n)
+	}
+}
+
+func TestMicrosecsMarshal(t *testing.T) {
+	v := struct {
+		Date Microsecs
+	}{Microsecs(time.Unix(12345, 6789))}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(out, []byte(`{"Date":12345000006}`)) {
+		t.Fatalf("times not equal - got %q", out)
+	}
+}
+
+func TestMicrosecsRoundtrip(t *testing.T) {
+	f := func(usecs int64) bool {
+		in := []byte(strconv.FormatInt(usecs, 10))
+
+		var us Microsecs
+		if err := us.UnmarshalJSON(in); err != nil {
+			t.Log(err)
+			return false
+		}
+
+		if !time.Time(us).Equal(time.Time(NewMicrosecs(usecs))) {
+			return false
+		}
+
+		out, err := us.MarshalJSON()
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		return bytes.Equal(in, out)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, edge := range []int64{math.MinInt64, math.MinInt64 + 1, -1, 0, 1, math.MaxInt64} {
+		if !f(edge) {
+			t.Fatal("edge case failed:", edge)
+		}
+	}
+}
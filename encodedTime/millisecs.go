@@ -1,39 +1,48 @@
 package encodedTime
 
 import (
-	"bytes"
-	"math"
-	"strconv"
+	"database/sql/driver"
 	"time"
 )
 
 // Millisecs is used to get a time from an js number that represents a timestamp in milliseconds
 type Millisecs time.Time
 
-// NewMillisecs returns a Millisecs instance with secs converted to millisecs (*1000)
-func NewMillisecs(secs int64) Millisecs {
-	return Millisecs(time.Unix(secs, 0))
+// NewMillisecs returns a Millisecs instance with msecs since unix-0
+func NewMillisecs(msecs int64) Millisecs {
+	return Millisecs(fromUnits(msecs, time.Millisecond))
 }
 
+// UnmarshalJSON for Millisecs accepts integers, fractions (1553708494043.0059) and exponents (1.5e12).
+// Sub-millisecond fractions are kept down to the nanosecond.
 func (t *Millisecs) UnmarshalJSON(in []byte) (err error) {
-	dot := []byte{'.'}
-	i := bytes.Index(in, dot)
-	if i == -1 {
-		i = 1000
-	} else {
-		i = int(math.Pow(10, float64(len(in)-i-2)))
-		in = bytes.Replace(in, dot, []byte{}, 1)
+	tv, null, err := decodeJSON(in, time.Millisecond, false, false)
+	if err != nil || null {
+		return err
 	}
-	secs, err := strconv.ParseInt(string(in), 10, 64)
+
+	*t = Millisecs(tv)
+	return nil
+}
+
+// MarshalJSON returns the whole milliseconds since unix-0, anything finer is floored
+func (t Millisecs) MarshalJSON() ([]byte, error) {
+	return marshalUnits(time.Time(t), time.Millisecond)
+}
+
+// Scan implements sql.Scanner. Integers, floats and numeric strings are read as milliseconds since unix-0,
+// time.Time values and textual timestamps are taken as they are.
+func (t *Millisecs) Scan(src interface{}) error {
+	tv, err := scanTime(src, time.Millisecond)
 	if err != nil {
 		return err
 	}
 
-	*t = Millisecs(time.Unix(secs/int64(i), 0))
+	*t = Millisecs(tv)
 	return nil
 }
 
-func (t Millisecs) MarshalJSON() ([]byte, error) {
-	secs := time.Time(t).UnixNano() / 1000000
-	return []byte(strconv.FormatInt(secs, 10)), nil
+// Value implements driver.Valuer and stores the whole milliseconds since unix-0 as an integer
+func (t Millisecs) Value() (driver.Value, error) {
+	return formatUnits(time.Time(t), time.Millisecond)
 }
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"testing"
+	"testing/quick"
 	"time"
 )
 
@@ -19,7 +22,7 @@ func TestMillisecsUnmarshall(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if n := time.Time(v.Timestamp).Sub(time.Unix(1449808143436/1000, 0)); n != 0 {
+	if n := time.Time(v.Timestamp).Sub(time.Unix(1449808143, 436*int64(time.Millisecond))); n != 0 {
 		t.Fatal(fmt.Errorf("times not equal:%d", n))
 	}
 }
@@ -36,11 +39,42 @@ func TestFloats(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if n := time.Time(v.Timestamp).Sub(time.Unix(15537084940430059/1000, 0)); n != 0 {
+	if n := time.Time(v.Timestamp).Sub(time.Unix(1553708494, 43005900)); n != 0 {
 		t.Fatal(fmt.Errorf("times not equal:%d", n))
 	}
 }
 
+func TestMillisecsExponent(t *testing.T) {
+	tcases := []struct {
+		in   string
+		want time.Time
+	}{
+		{`1.5e3`, time.Unix(1, 500*int64(time.Millisecond))},
+		{`1553708494043E0`, time.Unix(1553708494, 43*int64(time.Millisecond))},
+		{`15537084940430059e-4`, time.Unix(1553708494, 43005900)},
+		{`-1.5`, time.Unix(0, -1500000)},
+	}
+
+	for _, tc := range tcases {
+		var ms Millisecs
+		if err := json.Unmarshal([]byte(tc.in), &ms); err != nil {
+			t.Fatalf("%s: %s", tc.in, err)
+		}
+		if !time.Time(ms).Equal(tc.want) {
+			t.Fatalf("%s: got %s, want %s", tc.in, time.Time(ms), tc.want)
+		}
+	}
+}
+
+func TestMillisecsInvalid(t *testing.T) {
+	for _, in := range []string{`"123"`, `1.`, `0x10`, `1/2`, `1e999999999`, `9223372036854775807000`, `-9223372036854775808000`} {
+		var ms Millisecs
+		if err := ms.UnmarshalJSON([]byte(in)); err == nil {
+			t.Errorf("%s: expected error", in)
+		}
+	}
+}
+
 func TestMillisecsMarshal(t *testing.T) {
 
 	v := struct {
@@ -57,3 +91,35 @@ func TestMillisecsMarshal(t *testing.T) {
 	}
 
 }
+
+func TestMillisecsRoundtrip(t *testing.T) {
+	f := func(msecs int64) bool {
+		in := []byte(strconv.FormatInt(msecs, 10))
+
+		var ms Millisecs
+		if err := ms.UnmarshalJSON(in); err != nil {
+			t.Log(err)
+			return false
+		}
+
+		if !time.Time(ms).Equal(time.Time(NewMillisecs(msecs))) {
+			return false
+		}
+
+		out, err := ms.MarshalJSON()
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		return bytes.Equal(in, out)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, edge := range []int64{math.MinInt64, math.MinInt64 + 1, -1, 0, 1, math.MaxInt64} {
+		if !f(edge) {
+			t.Fatal("edge case failed:", edge)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package encodedTime
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// Nanosecs is used to get a time from a number that represents a timestamp in nanoseconds
+type Nanosecs time.Time
+
+// NewNanosecs returns a Nanosecs instance with nsecs since unix-0
+func NewNanosecs(nsecs int64) Nanosecs {
+	return Nanosecs(time.Unix(0, nsecs))
+}
+
+// UnmarshalJSON for Nanosecs accepts integers, fractions and exponents. Fractions of a nanosecond are floored.
+func (t *Nanosecs) UnmarshalJSON(in []byte) (err error) {
+	tv, null, err := decodeJSON(in, time.Nanosecond, false, false)
+	if err != nil || null {
+		return err
+	}
+
+	*t = Nanosecs(tv)
+	return nil
+}
+
+// MarshalJSON returns the nanoseconds since unix-0
+func (t Nanosecs) MarshalJSON() ([]byte, error) {
+	return marshalUnits(time.Time(t), time.Nanosecond)
+}
+
+// Scan implements sql.Scanner. Integers, floats and numeric strings are read as nanoseconds since unix-0,
+// time.Time values and textual timestamps are taken as they are.
+func (t *Nanosecs) Scan(src interface{}) error {
+	tv, err := scanTime(src, time.Nanosecond)
+	if err != nil {
+		return err
+	}
+
+	*t = Nanosecs(tv)
+	return nil
+}
+
+// Value implements driver.Valuer and stores the whole nanoseconds since unix-0 as an integer
+func (t Nanosecs) Value() (driver.Value, error) {
+	return formatUnits(time.Time(t), time.Nanosecond)
+}
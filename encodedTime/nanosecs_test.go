@@ -0,0 +1,73 @@
+package encodedTime
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+	"testing/quick"
+	"time"
+)
+
+func TestNanosecsUnmarshall(t *testing.T) {
+	v := struct {
+		Timestamp Nanosecs
+	}{}
+
+	err := json.Unmarshal([]byte(`{"Timestamp":1449808143436123456}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := time.Time(v.Timestamp).Sub(time.Unix(1449808143, 436123456)); n != 0 {
+		t.Fatalf("times not equal:%d", n)
+	}
+}
+
+func TestNanosecsMarshal(t *testing.T) {
+	v := struct {
+		Date Nanosecs
+	}{Nanosecs(time.Unix(12345, 6789))}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(out, []byte(`{"Date":12345000006789}`)) {
+		t.Fatalf("times not equal - got %q", out)
+	}
+}
+
+func TestNanosecsRoundtrip(t *testing.T) {
+	f := func(nsecs int64) bool {
+		in := []byte(strconv.FormatInt(nsecs, 10))
+
+		var ns Nanosecs
+		if err := ns.UnmarshalJSON(in); err != nil {
+			t.Log(err)
+			return false
+		}
+
+		if !time.Time(ns).Equal(time.Unix(0, nsecs)) {
+			return false
+		}
+
+		out, err := ns.MarshalJSON()
+		if err != nil {
+			t.Log(err)
+			return false
+		}
+		return bytes.Equal(in, out)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, edge := range []int64{math.MinInt64, math.MinInt64 + 1, -1, 0, 1, math.MaxInt64} {
+		if !f(edge) {
+			t.Fatal("edge case failed:", edge)
+		}
+	}
+}
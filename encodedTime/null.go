@@ -0,0 +1,106 @@
+package encodedTime
+
+import (
+	"database/sql/driver"
+	"time"
+)
+
+// NullUnix is a Unix timestamp that may be null, like sql.NullTime.
+// It marshals to null if Valid is false.
+type NullUnix struct {
+	Time  time.Time
+	Valid bool
+}
+
+// UnmarshalJSON sets Valid to false for null, otherwise it decodes like Unix
+func (t *NullUnix) UnmarshalJSON(in []byte) error {
+	tv, null, err := decodeJSON(in, time.Second, true, false)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// MarshalJSON returns null or the seconds since unix-0
+func (t NullUnix) MarshalJSON() ([]byte, error) {
+	if !t.Valid {
+		return jsonNull, nil
+	}
+	return Unix(t.Time).MarshalJSON()
+}
+
+// Scan implements sql.Scanner. NULL sets Valid to false, see Unix.Scan for the rest.
+func (t *NullUnix) Scan(src interface{}) error {
+	if src == nil {
+		t.Time, t.Valid = time.Time{}, false
+		return nil
+	}
+
+	tv, err := scanTime(src, time.Second)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, true
+	return nil
+}
+
+// Value implements driver.Valuer and returns NULL if Valid is false
+func (t NullUnix) Value() (driver.Value, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return Unix(t.Time).Value()
+}
+
+// NullMillisecs is a Millisecs timestamp that may be null, like sql.NullTime.
+// It marshals to null if Valid is false.
+type NullMillisecs struct {
+	Time  time.Time
+	Valid bool
+}
+
+// UnmarshalJSON sets Valid to false for null, otherwise it decodes like Millisecs
+func (t *NullMillisecs) UnmarshalJSON(in []byte) error {
+	tv, null, err := decodeJSON(in, time.Millisecond, false, false)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, !null
+	return nil
+}
+
+// MarshalJSON returns null or the milliseconds since unix-0
+func (t NullMillisecs) MarshalJSON() ([]byte, error) {
+	if !t.Valid {
+		return jsonNull, nil
+	}
+	return Millisecs(t.Time).MarshalJSON()
+}
+
+// Scan implements sql.Scanner. NULL sets Valid to false, see Millisecs.Scan for the rest.
+func (t *NullMillisecs) Scan(src interface{}) error {
+	if src == nil {
+		t.Time, t.Valid = time.Time{}, false
+		return nil
+	}
+
+	tv, err := scanTime(src, time.Millisecond)
+	if err != nil {
+		return err
+	}
+
+	t.Time, t.Valid = tv, true
+	return nil
+}
+
+// Value implements driver.Valuer and returns NULL if Valid is false
+func (t NullMillisecs) Value() (driver.Value, error) {
+	if !t.Valid {
+		return nil, nil
+	}
+	return Millisecs(t.Time).Value()
+}
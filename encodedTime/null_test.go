@@ -0,0 +1,85 @@
+package encodedTime
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNullUnixJSON(t *testing.T) {
+	var v struct {
+		A NullUnix
+		B NullUnix
+	}
+
+	err := json.Unmarshal([]byte(`{"A":12345,"B":null}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !v.A.Valid || !v.A.Time.Equal(time.Unix(12345, 0)) {
+		t.Errorf("wrong A: %+v", v.A)
+	}
+	if v.B.Valid {
+		t.Errorf("B should be invalid: %+v", v.B)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"A":12345,"B":null}` {
+		t.Errorf("wrong output: %s", out)
+	}
+}
+
+func TestNullMillisecsJSON(t *testing.T) {
+	var v struct {
+		A NullMillisecs
+		B NullMillisecs
+	}
+
+	err := json.Unmarshal([]byte(`{"A":1449808143436,"B":null}`), &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !v.A.Valid || !v.A.Time.Equal(time.Unix(1449808143, 436*int64(time.Millisecond))) {
+		t.Errorf("wrong A: %+v", v.A)
+	}
+	if v.B.Valid {
+		t.Errorf("B should be invalid: %+v", v.B)
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(out) != `{"A":1449808143436,"B":null}` {
+		t.Errorf("wrong output: %s", out)
+	}
+}
+
+func TestNullSQL(t *testing.T) {
+	var nu NullUnix
+	if err := nu.Scan(nil); err != nil {
+		t.Fatal(err)
+	}
+	if nu.Valid {
+		t.Fatal("NULL should be invalid")
+	}
+	if v, err := nu.Value(); err != nil || v != nil {
+		t.Fatalf("expected nil value: %v %v", v, err)
+	}
+
+	var nm NullMillisecs
+	if err := nm.Scan(int64(12345)); err != nil {
+		t.Fatal(err)
+	}
+	if !nm.Valid || !nm.Time.Equal(time.Unix(12, 345*int64(time.Millisecond))) {
+		t.Fatalf("wrong value: %+v", nm)
+	}
+	if v, err := nm.Value(); err != nil || v != int64(12345) {
+		t.Fatalf("wrong value: %v %v", v, err)
+	}
+}
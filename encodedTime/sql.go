@@ -0,0 +1,52 @@
+package encodedTime
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// sqlLayouts are the textual time formats scanTime understands, besides plain numbers.
+// The second one is what the common SQLite drivers write for time.Time values.
+var sqlLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// scanTime converts a database column value into a time.
+// Numbers (also as text) are interpreted as units since unix-0.
+func scanTime(src interface{}, unit time.Duration) (time.Time, error) {
+	switch v := src.(type) {
+	case int64:
+		return fromUnits(v, unit), nil
+	case float64:
+		return parseUnits([]byte(strconv.FormatFloat(v, 'g', -1, 64)), unit)
+	case []byte:
+		return scanText(v, unit)
+	case string:
+		return scanText([]byte(v), unit)
+	case time.Time:
+		return v, nil
+	case nil:
+		return time.Time{}, fmt.Errorf("encodedTime: can't scan NULL, use a Null type")
+	}
+	return time.Time{}, fmt.Errorf("encodedTime: can't scan %T", src)
+}
+
+func scanText(in []byte, unit time.Duration) (time.Time, error) {
+	in = bytes.TrimSpace(in)
+	if t, err := parseUnits(in, unit); err == nil {
+		return t, nil
+	}
+	for _, layout := range sqlLayouts {
+		if t, err := time.Parse(layout, string(in)); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("encodedTime: can't scan %q as number or time", in)
+}
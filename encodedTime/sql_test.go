@@ -0,0 +1,207 @@
+package encodedTime
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memDriver is a minimal database/sql driver that keeps a single column table in memory.
+// "INSERT" appends its argument and "SELECT" returns all stored values, unconverted.
+type memDriver struct {
+	mu     sync.Mutex
+	tables map[string][]driver.Value
+}
+
+var testDriver = &memDriver{tables: make(map[string][]driver.Value)}
+
+func init() {
+	sql.Register("encodedTime-mem", testDriver)
+}
+
+func (d *memDriver) Open(name string) (driver.Conn, error) {
+	return memConn{d: d, table: name}, nil
+}
+
+type memConn struct {
+	d     *memDriver
+	table string
+}
+
+func (c memConn) Prepare(query string) (driver.Stmt, error) {
+	return memStmt{c: c, query: strings.ToUpper(strings.TrimSpace(query))}, nil
+}
+func (c memConn) Close() error              { return nil }
+func (c memConn) Begin() (driver.Tx, error) { return nil, errors.New("memDriver: no transactions") }
+
+type memStmt struct {
+	c     memConn
+	query string
+}
+
+func (s memStmt) Close() error { return nil }
+
+func (s memStmt) NumInput() int {
+	if strings.HasPrefix(s.query, "INSERT") {
+		return 1
+	}
+	return 0
+}
+
+func (s memStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if !strings.HasPrefix(s.query, "INSERT") {
+		return nil, errors.New("memDriver: unsupported exec: " + s.query)
+	}
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	s.c.d.tables[s.c.table] = append(s.c.d.tables[s.c.table], args[0])
+	return driver.RowsAffected(1), nil
+}
+
+func (s memStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if !strings.HasPrefix(s.query, "SELECT") {
+		return nil, errors.New("memDriver: unsupported query: " + s.query)
+	}
+	s.c.d.mu.Lock()
+	defer s.c.d.mu.Unlock()
+	vals := make([]driver.Value, len(s.c.d.tables[s.c.table]))
+	copy(vals, s.c.d.tables[s.c.table])
+	return &memRows{vals: vals}, nil
+}
+
+type memRows struct {
+	vals []driver.Value
+}
+
+func (r *memRows) Columns() []string { return []string{"ts"} }
+func (r *memRows) Close() error      { return nil }
+
+func (r *memRows) Next(dest []driver.Value) error {
+	if len(r.vals) == 0 {
+		return io.EOF
+	}
+	dest[0], r.vals = r.vals[0], r.vals[1:]
+	return nil
+}
+
+// openMemDB returns a handle on an empty table named after the test, so that tests can be repeated with -count
+func openMemDB(t *testing.T) *sql.DB {
+	testDriver.mu.Lock()
+	delete(testDriver.tables, t.Name())
+	testDriver.mu.Unlock()
+
+	db, err := sql.Open("encodedTime-mem", t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestSQLRoundtrip(t *testing.T) {
+	db := openMemDB(t)
+	defer db.Close()
+
+	want := time.Unix(1449808143, 436123456)
+	ins := []interface{}{
+		Unix(want),
+		Millisecs(want),
+		Microsecs(want),
+		Nanosecs(want),
+	}
+	for _, v := range ins {
+		if _, err := db.Exec("INSERT ?", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var (
+		u  Unix
+		ms Millisecs
+		us Microsecs
+		ns Nanosecs
+	)
+	outs := []interface{}{&u, &ms, &us, &ns}
+	for i := 0; rows.Next(); i++ {
+		if err := rows.Scan(outs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := []struct {
+		got  time.Time
+		want time.Time
+	}{
+		{time.Time(u), want.Truncate(time.Second)},
+		{time.Time(ms), want.Truncate(time.Millisecond)},
+		{time.Time(us), want.Truncate(time.Microsecond)},
+		{time.Time(ns), want},
+	}
+	for i, c := range checks {
+		if !c.got.Equal(c.want) {
+			t.Errorf("%d: got %s, want %s", i, c.got, c.want)
+		}
+	}
+}
+
+func TestSQLScanColumnTypes(t *testing.T) {
+	db := openMemDB(t)
+	defer db.Close()
+
+	want := time.Unix(1449808143, 500*int64(time.Millisecond)).UTC()
+	ins := []interface{}{
+		int64(1449808143500),
+		float64(1449808143500),
+		"1449808143500",
+		[]byte("1.4498081435e12"),
+		want,
+		"2015-12-11T04:29:03.5Z",
+		"2015-12-11 04:29:03.5+00:00",
+	}
+	for _, v := range ins {
+		if _, err := db.Exec("INSERT ?", v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	for i := 0; rows.Next(); i++ {
+		var ms Millisecs
+		if err := rows.Scan(&ms); err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		if !time.Time(ms).Equal(want) {
+			t.Errorf("%d: got %s, want %s", i, time.Time(ms), want)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSQLScanInvalid(t *testing.T) {
+	for _, src := range []interface{}{nil, true, "yesterday", []byte("0x10")} {
+		var u Unix
+		if err := u.Scan(src); err == nil {
+			t.Errorf("%v: expected error", src)
+		}
+	}
+}
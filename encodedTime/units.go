@@ -0,0 +1,88 @@
+package encodedTime
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// jsonNumber matches the number grammar of RFC 8259
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?([0-9]+))?$`)
+
+// maxExponent guards against inputs like 1e999999999 which would make math/big allocate huge numbers
+const maxExponent = 100
+
+var nanosPerSec = big.NewInt(int64(time.Second))
+
+// maxUnixSec is the largest second count time.Time can hold without overflowing its internal representation (seconds since year 1)
+const maxUnixSec = math.MaxInt64 - (1969*365+1969/4-1969/100+1969/400)*24*60*60
+
+// minUnixSec mirrors maxUnixSec, below it the calendar computations of time.Time wrap around
+const minUnixSec = -maxUnixSec
+
+// parseUnits parses a decimal number that counts units since the unix epoch.
+// Fractions and exponents are honored down to the nanosecond; anything finer is floored.
+func parseUnits(in []byte, unit time.Duration) (time.Time, error) {
+	m := jsonNumber.FindSubmatch(in)
+	if m == nil {
+		return time.Time{}, fmt.Errorf("encodedTime: invalid number %q", in)
+	}
+
+	if len(m[4]) > 0 {
+		exp, err := strconv.Atoi(string(m[4]))
+		if err != nil || exp > maxExponent {
+			return time.Time{}, fmt.Errorf("encodedTime: exponent of %q out of range", in)
+		}
+	}
+
+	r, ok := new(big.Rat).SetString(string(in))
+	if !ok {
+		return time.Time{}, fmt.Errorf("encodedTime: invalid number %q", in)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(int64(unit)))
+
+	// the denominator is always positive so Div floors towards negative infinity
+	ns := new(big.Int).Div(r.Num(), r.Denom())
+
+	sec, nsec := new(big.Int).DivMod(ns, nanosPerSec, new(big.Int))
+	if !sec.IsInt64() || sec.Int64() > maxUnixSec || sec.Int64() < minUnixSec {
+		return time.Time{}, fmt.Errorf("encodedTime: %q out of range", in)
+	}
+
+	return time.Unix(sec.Int64(), nsec.Int64()), nil
+}
+
+// formatUnits returns the number of whole units since the unix epoch, floored.
+func formatUnits(t time.Time, unit time.Duration) (int64, error) {
+	ns := big.NewInt(t.Unix())
+	ns.Mul(ns, nanosPerSec)
+	ns.Add(ns, big.NewInt(int64(t.Nanosecond())))
+	ns.Div(ns, big.NewInt(int64(unit)))
+	if !ns.IsInt64() {
+		return 0, fmt.Errorf("encodedTime: %s can't be expressed in %s", t, unit)
+	}
+	return ns.Int64(), nil
+}
+
+// marshalUnits is the shared MarshalJSON implementation of the numeric types
+func marshalUnits(t time.Time, unit time.Duration) ([]byte, error) {
+	n, err := formatUnits(t, unit)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(strconv.FormatInt(n, 10)), nil
+}
+
+// fromUnits constructs a time from n units since the unix epoch
+func fromUnits(n int64, unit time.Duration) time.Time {
+	perSec := int64(time.Second / unit)
+	sec, rest := n/perSec, n%perSec
+	if rest < 0 {
+		sec--
+		rest += perSec
+	}
+	return time.Unix(sec, rest*int64(unit))
+}
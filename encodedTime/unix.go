@@ -1,6 +1,7 @@
 package encodedTime
 
 import (
+	"database/sql/driver"
 	"strconv"
 	"time"
 )
@@ -13,14 +14,15 @@ func NewUnix(secs int64) Unix {
 	return Unix(time.Unix(secs, 0))
 }
 
-// UnmarshalJSON for Unix converts the []byte value to int64 seconds and than constructs the time with time.Unix()
+// UnmarshalJSON for Unix converts the []byte value to int64 seconds and than constructs the time with time.Unix().
+// null is a no-op, see LenientUnix for a variant that accepts more inputs.
 func (t *Unix) UnmarshalJSON(in []byte) (err error) {
-	secs, err := strconv.ParseInt(string(in), 10, 64)
-	if err != nil {
+	tv, null, err := decodeJSON(in, time.Second, true, false)
+	if err != nil || null {
 		return err
 	}
 
-	*t = Unix(time.Unix(secs, 0))
+	*t = Unix(tv)
 	return nil
 }
 
@@ -29,3 +31,20 @@ func (t Unix) MarshalJSON() ([]byte, error) {
 	secs := time.Time(t).Unix()
 	return []byte(strconv.FormatInt(secs, 10)), nil
 }
+
+// Scan implements sql.Scanner. Integers, floats and numeric strings are read as seconds since unix-0,
+// time.Time values and textual timestamps are taken as they are.
+func (t *Unix) Scan(src interface{}) error {
+	tv, err := scanTime(src, time.Second)
+	if err != nil {
+		return err
+	}
+
+	*t = Unix(tv)
+	return nil
+}
+
+// Value implements driver.Valuer and stores the whole seconds since unix-0 as an integer
+func (t Unix) Value() (driver.Value, error) {
+	return formatUnits(time.Time(t), time.Second)
+}
@@ -41,3 +41,17 @@ func TestUnixMarshal(t *testing.T) {
 	}
 
 }
+
+func TestUnixOutOfRange(t *testing.T) {
+	for _, in := range []string{`9223372036854775807`, `-9223372036854775808`, `-9223371974719179008`} {
+		var u Unix
+		if err := u.UnmarshalJSON([]byte(in)); err == nil {
+			t.Errorf("%s: expected error, got %s", in, time.Time(u))
+		}
+	}
+
+	var u Unix
+	if err := u.UnmarshalJSON([]byte(`-9223371974719179007`)); err != nil || time.Time(u).Year() > 0 {
+		t.Errorf("smallest second count: %v %d", err, time.Time(u).Year())
+	}
+}
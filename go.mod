@@ -1,6 +1,7 @@
 module go.mindeco.de
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/PuerkitoBio/goquery v1.5.0
 	github.com/davecgh/go-spew v1.1.1
 	github.com/dustin/go-humanize v1.0.0
@@ -11,9 +12,16 @@ require (
 	github.com/gorilla/sessions v1.1.3
 	github.com/miolini/datacounter v0.0.0-20171104152933-fd4e42a1d5e0
 	github.com/oxtoacart/bpool v0.0.0-20190524125616-8c0b41497736
-	github.com/pkg/errors v0.8.1
+	github.com/pkg/errors v0.9.1
 	github.com/shurcooL/httpfs v0.0.0-20190527155220-6a4d4a70508b
 	github.com/stretchr/testify v1.3.0
 )
 
-go 1.13
+require (
+	github.com/andybalholm/cascadia v1.0.0 // indirect
+	github.com/gorilla/context v1.1.1 // indirect
+	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.0.0-20181114220301-adae6a3d119a // indirect
+)
+
+go 1.18
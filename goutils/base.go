@@ -5,28 +5,61 @@ import (
 	"os"
 	"path/filepath"
 
-	"go.mindeco.de/logging"
 	"github.com/pkg/errors"
+	"go.mindeco.de/logging"
 )
 
-// LocatePackage searches for the import path and returns the os filesystem path location
+// LocatePackage searches for the import path and returns the os filesystem path location, relative to the working directory.
+//
+// Inside a module it reads the go.mod of the main module and looks at the module itself, its vendor directory,
+// replace directives and the local module cache, without touching the network.
+// Outside of a module it falls back to GOPATH lookups.
 func LocatePackage(path string) (string, error) {
-	p, err := build.Default.Import(path, "", build.FindOnly)
+	cwd, err := os.Getwd()
 	if err != nil {
-		return "", errors.Wrap(err, "LocatePackage: failed to find import")
+		return "", errors.Wrap(err, "LocatePackage: could not get working directory")
 	}
 
-	cwd, err := os.Getwd()
+	dir, err := locateImport(path, cwd)
 	if err != nil {
-		return "", errors.Wrap(err, "LocatePackage: could not get working directory")
+		return "", errors.Wrap(err, "LocatePackage: failed to find import")
 	}
 
-	p.Dir, err = filepath.Rel(cwd, p.Dir)
+	dir, err = filepath.Rel(cwd, dir)
 	if err != nil {
 		return "", errors.Wrap(err, "LocatePackage: could not construct relative path")
 	}
 
-	return p.Dir, nil
+	return dir, nil
+}
+
+func locateImport(path, cwd string) (string, error) {
+	var mf *goMod
+	if gomod := findGoMod(cwd); gomod != "" && os.Getenv("GO111MODULE") != "off" {
+		var err error
+		if mf, err = parseGoMod(gomod); err != nil {
+			return "", err
+		}
+		// the main module may have a dotless path, too
+		if _, ok := subPath(path, mf.Module); ok {
+			return mf.locate(path)
+		}
+	}
+
+	if isStdlib(path) {
+		if dir, err := existingDir(filepath.Join(build.Default.GOROOT, "src", filepath.FromSlash(path))); err == nil {
+			return dir, nil
+		}
+	}
+
+	if mf == nil {
+		p, err := build.Default.Import(path, cwd, build.FindOnly)
+		if err != nil {
+			return "", err
+		}
+		return p.Dir, nil
+	}
+	return mf.locate(path)
 }
 
 func MustLocatePackage(path string) string {
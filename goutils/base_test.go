@@ -0,0 +1,195 @@
+package goutils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testGoMod = `module example.com/main
+
+go 1.13
+
+require (
+	example.com/dep v1.2.3
+	example.com/local v0.0.0 // replaced below
+	example.com/old v1.0.0
+	example.com/vend v1.0.0
+	github.com/Upper/Case v0.1.0 // indirect
+)
+
+replace example.com/local => ./local
+
+replace (
+	example.com/old => example.com/wrong v0.0.1
+	example.com/old v1.0.0 => example.com/new v2.0.0
+)
+`
+
+func TestLocatePackageModules(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "goutils-locate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	if tmp, err = filepath.EvalSymlinks(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range []string{
+		"main/sub/pkg",
+		"main/local/pkg",
+		"main/vendor/example.com/vend/pkg",
+		"cache/example.com/dep@v1.2.3/pkg",
+		"cache/example.com/new@v2.0.0/x",
+		"cache/github.com/!upper/!case@v0.1.0",
+	} {
+		if err := os.MkdirAll(filepath.Join(tmp, filepath.FromSlash(d)), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, "main", "go.mod"), []byte(testGoMod), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tmp, "main", "vendor", "modules.txt"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(oldwd)
+	if err := os.Chdir(filepath.Join(tmp, "main", "sub")); err != nil {
+		t.Fatal(err)
+	}
+
+	restore := setenv(t, "GOMODCACHE", filepath.Join(tmp, "cache"))
+	defer restore()
+	restore = setenv(t, "GOFLAGS", "")
+	defer restore()
+
+	tcases := []struct {
+		path string
+		want string
+	}{
+		{"example.com/main/sub/pkg", "pkg"},
+		{"example.com/main", ".."},
+		{"example.com/local/pkg", "../local/pkg"},
+		{"example.com/vend/pkg", "../vendor/example.com/vend/pkg"},
+		{"example.com/dep/pkg", "../../cache/example.com/dep@v1.2.3/pkg"},
+		{"example.com/old/x", "../../cache/example.com/new@v2.0.0/x"},
+		{"github.com/Upper/Case", "../../cache/github.com/!upper/!case@v0.1.0"},
+	}
+	for _, tc := range tcases {
+		got, err := LocatePackage(tc.path)
+		if err != nil {
+			t.Errorf("%s: %s", tc.path, err)
+			continue
+		}
+		if got != filepath.FromSlash(tc.want) {
+			t.Errorf("%s: got %s, want %s", tc.path, got, tc.want)
+		}
+	}
+
+	for _, p := range []string{"example.com/unknown", "example.com/dep/missing", "example.com/main/nope"} {
+		if _, err := LocatePackage(p); err == nil {
+			t.Errorf("%s: expected error", p)
+		}
+	}
+
+	// -mod=mod disables the vendor directory
+	restore = setenv(t, "GOFLAGS", "-mod=mod")
+	defer restore()
+	if _, err := LocatePackage("example.com/vend/pkg"); err == nil {
+		t.Error("vendor directory should be ignored")
+	}
+}
+
+func TestLocatePackageThisModule(t *testing.T) {
+	got, err := LocatePackage("go.mindeco.de/http/render")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != filepath.FromSlash("../http/render") {
+		t.Fatalf("wrong path: %s", got)
+	}
+
+	if _, err := LocatePackage("net/http"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLocatePackageDotlessModule(t *testing.T) {
+	tmp, err := ioutil.TempDir("", "goutils-locate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmp)
+	if tmp, err = filepath.EvalSymlinks(tmp); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, d := range []string{"myapp/sub", "shared/pkg"} {
+		if err := os.MkdirAll(filepath.Join(tmp, filepath.FromSlash(d)), 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	gomod := "module myapp\n\ngo 1.13\n\nrequire shared v0.0.0\n\nreplace shared => ../shared\n"
+	if err := ioutil.WriteFile(filepath.Join(tmp, "myapp", "go.mod"), []byte(gomod), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	oldwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(oldwd)
+	if err := os.Chdir(filepath.Join(tmp, "myapp")); err != nil {
+		t.Fatal(err)
+	}
+
+	restore := setenv(t, "GOFLAGS", "")
+	defer restore()
+
+	tcases := []struct {
+		path string
+		want string
+	}{
+		{"myapp/sub", "sub"},
+		{"myapp", "."},
+		{"shared/pkg", "../shared/pkg"},
+	}
+	for _, tc := range tcases {
+		got, err := LocatePackage(tc.path)
+		if err != nil {
+			t.Errorf("%s: %s", tc.path, err)
+			continue
+		}
+		if got != filepath.FromSlash(tc.want) {
+			t.Errorf("%s: got %s, want %s", tc.path, got, tc.want)
+		}
+	}
+
+	if _, err := LocatePackage("net/http"); err != nil {
+		t.Error(err)
+	}
+	if _, err := LocatePackage("myapp/nope"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func setenv(t *testing.T, key, val string) func() {
+	old, had := os.LookupEnv(key)
+	if err := os.Setenv(key, val); err != nil {
+		t.Fatal(err)
+	}
+	return func() {
+		if had {
+			os.Setenv(key, old)
+		} else {
+			os.Unsetenv(key)
+		}
+	}
+}
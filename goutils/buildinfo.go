@@ -0,0 +1,143 @@
+package goutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+)
+
+// These can be set at link time and take precedence over what the go command recorded, for instance:
+//
+//	go build -ldflags "-X go.mindeco.de/goutils.Version=v1.2.3 -X go.mindeco.de/goutils.BuildTime=$(date -u +%FT%TZ)"
+var (
+	// Version overwrites the version of the main module
+	Version string
+
+	// BuildTime is the RFC3339 time of the build. Without it the time of the VCS commit is reported.
+	BuildTime string
+)
+
+// Module describes one module that is part of a binary
+type Module struct {
+	Path    string
+	Version string
+	Sum     string  `json:",omitempty"`
+	Replace *Module `json:",omitempty"`
+}
+
+// BuildInfo describes how a binary was built
+type BuildInfo struct {
+	GoVersion string
+	Path      string // the import path of the main package
+	Main      Module
+
+	Revision  string    `json:",omitempty"` // the VCS revision
+	Dirty     bool      // whether the working tree had uncommitted changes
+	BuildTime time.Time `json:",omitempty"`
+
+	Deps []Module
+}
+
+// ReadBuildInfo returns the build information embedded in the running binary
+func ReadBuildInfo() (*BuildInfo, error) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return nil, errors.New("ReadBuildInfo: binary was built without module support")
+	}
+	return newBuildInfo(bi)
+}
+
+func newBuildInfo(bi *debug.BuildInfo) (*BuildInfo, error) {
+	info := BuildInfo{
+		GoVersion: bi.GoVersion,
+		Path:      bi.Path,
+		Main:      convertModule(&bi.Main),
+		Deps:      make([]Module, len(bi.Deps)),
+	}
+	for i, d := range bi.Deps {
+		info.Deps[i] = convertModule(d)
+	}
+
+	var vcsTime string
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.modified":
+			info.Dirty = s.Value == "true"
+		case "vcs.time":
+			vcsTime = s.Value
+		}
+	}
+
+	if Version != "" {
+		info.Main.Version = Version
+	}
+
+	if BuildTime != "" {
+		vcsTime = BuildTime
+	}
+	if vcsTime != "" {
+		t, err := time.Parse(time.RFC3339, vcsTime)
+		if err != nil {
+			return nil, errors.Wrap(err, "ReadBuildInfo: invalid build time")
+		}
+		info.BuildTime = t
+	}
+
+	return &info, nil
+}
+
+func convertModule(m *debug.Module) Module {
+	mod := Module{
+		Path:    m.Path,
+		Version: m.Version,
+		Sum:     m.Sum,
+	}
+	if m.Replace != nil {
+		r := convertModule(m.Replace)
+		mod.Replace = &r
+	}
+	return mod
+}
+
+// BuildInfoHandler serves the result of ReadBuildInfo as JSON
+func BuildInfoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		info, err := ReadBuildInfo()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(info)
+	})
+}
+
+// LogBuildInfo logs version, revision and build time of the running binary, for instance at startup
+func LogBuildInfo(log kitlog.Logger) {
+	info, err := ReadBuildInfo()
+	if err != nil {
+		log.Log("event", "buildinfo", "err", err)
+		return
+	}
+
+	kv := []interface{}{
+		"event", "buildinfo",
+		"path", info.Path,
+		"version", info.Main.Version,
+		"go", info.GoVersion,
+		"deps", len(info.Deps),
+	}
+	if info.Revision != "" {
+		kv = append(kv, "revision", info.Revision, "dirty", info.Dirty)
+	}
+	if !info.BuildTime.IsZero() {
+		kv = append(kv, "buildTime", info.BuildTime.Format(time.RFC3339))
+	}
+	log.Log(kv...)
+}
@@ -0,0 +1,94 @@
+package goutils
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime/debug"
+	"strings"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+)
+
+func TestNewBuildInfo(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.21.0",
+		Path:      "example.com/cmd/server",
+		Main:      debug.Module{Path: "example.com", Version: "v1.0.0"},
+		Deps: []*debug.Module{
+			{Path: "example.com/dep", Version: "v0.1.0", Sum: "h1:abc"},
+			{Path: "example.com/old", Version: "v1.0.0", Replace: &debug.Module{Path: "../local", Version: "(devel)"}},
+		},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs", Value: "git"},
+			{Key: "vcs.revision", Value: "0123456789abcdef"},
+			{Key: "vcs.time", Value: "2020-09-13T12:26:40Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	info, err := newBuildInfo(bi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Revision != "0123456789abcdef" || !info.Dirty || info.Main.Version != "v1.0.0" {
+		t.Errorf("wrong info: %+v", info)
+	}
+	if !info.BuildTime.Equal(time.Unix(1600000000, 0)) {
+		t.Errorf("wrong build time: %s", info.BuildTime)
+	}
+	if len(info.Deps) != 2 || info.Deps[1].Replace == nil || info.Deps[1].Replace.Path != "../local" {
+		t.Errorf("wrong deps: %+v", info.Deps)
+	}
+
+	Version, BuildTime = "v1.2.3", "2020-09-13T12:26:41Z"
+	defer func() { Version, BuildTime = "", "" }()
+
+	info, err = newBuildInfo(bi)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Main.Version != "v1.2.3" || !info.BuildTime.Equal(time.Unix(1600000001, 0)) {
+		t.Errorf("link time values not used: %+v", info)
+	}
+
+	BuildTime = "yesterday"
+	if _, err := newBuildInfo(bi); err == nil {
+		t.Error("expected error for invalid build time")
+	}
+}
+
+func TestBuildInfoHandler(t *testing.T) {
+	rw := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/version", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	BuildInfoHandler().ServeHTTP(rw, req)
+
+	if rw.Code != http.StatusOK {
+		t.Fatalf("wrong status: %d %s", rw.Code, rw.Body)
+	}
+	if ct := rw.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("wrong content type: %s", ct)
+	}
+
+	var info BuildInfo
+	if err := json.NewDecoder(rw.Body).Decode(&info); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(info.GoVersion, "go") {
+		t.Fatalf("wrong go version: %q", info.GoVersion)
+	}
+}
+
+func TestLogBuildInfo(t *testing.T) {
+	var buf bytes.Buffer
+	LogBuildInfo(kitlog.NewLogfmtLogger(&buf))
+	if !strings.Contains(buf.String(), "event=buildinfo") || !strings.Contains(buf.String(), "go=go") {
+		t.Fatalf("wrong log line: %s", buf.String())
+	}
+}
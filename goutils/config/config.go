@@ -0,0 +1,438 @@
+/*
+Package config fills a struct from defaults, a config file, environment variables and command-line flags,
+in that order of precedence.
+
+Fields are configured with struct tags:
+
+	type Config struct {
+		Listen  string        `config:"listen" default:":8080" usage:"address to listen on"`
+		Timeout time.Duration `default:"30s"`
+		Debug   bool
+		DB      struct {
+			URL string `config:"url" required:"true"`
+		}
+		Internal string `config:"-"`
+	}
+
+The key of a field is the value of its config tag or its name in snake_case (MaxConns => max_conns).
+Nested structs are sections: db.url in JSON and TOML files, PREFIX_DB_URL in the environment and -db.url as a flag.
+Supported are strings, bools, integers, floats, time.Duration, string slices (comma separated in the environment and flags)
+and everything that implements encoding.TextUnmarshaler.
+
+Invalid values are reported as *FieldError, which names the field and where the value came from.
+If the struct implements Validator, Validate is called last.
+*/
+package config
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// Validator can be implemented by config structs to check the final values
+type Validator interface {
+	Validate() error
+}
+
+// FieldError reports an invalid value and where it came from
+type FieldError struct {
+	Field  string // the key of the field, like db.url
+	Source string // like default, file config.toml, env APP_DB_URL or flag -db.url
+	Value  string
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	if e.Source == "required" {
+		return fmt.Sprintf("config: %s is required", e.Field)
+	}
+	return fmt.Sprintf("config: invalid value %q for %s from %s: %s", e.Value, e.Field, e.Source, e.Err)
+}
+
+// Cause returns the underlying error for github.com/pkg/errors
+func (e *FieldError) Cause() error { return e.Err }
+
+// Unwrap returns the underlying error for the errors package of the standard library
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// Errors collects all FieldErrors of one Load
+type Errors []*FieldError
+
+func (es Errors) Error() string {
+	msgs := make([]string, len(es))
+	for i, e := range es {
+		msgs[i] = e.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// Option configures the sources of Load
+type Option func(*loader) error
+
+type loader struct {
+	file      string
+	envPrefix string
+	useEnv    bool
+	lookupEnv func(string) (string, bool)
+
+	flags    *flag.FlagSet
+	flagArgs []string
+}
+
+// File reads the config file at path. The format is chosen by the extension: .json or .toml.
+func File(path string) Option {
+	return func(l *loader) error {
+		if path == "" {
+			return errors.New("config: empty file path")
+		}
+		l.file = path
+		return nil
+	}
+}
+
+// Env reads environment variables named PREFIX_KEY. An empty prefix uses just the key.
+func Env(prefix string) Option {
+	return func(l *loader) error {
+		l.useEnv = true
+		l.envPrefix = prefix
+		return nil
+	}
+}
+
+// Environ uses the variables in env (KEY=value) instead of the process environment. It implies Env("") unless Env is also passed.
+func Environ(env []string) Option {
+	return func(l *loader) error {
+		vals := make(map[string]string, len(env))
+		for _, kv := range env {
+			if i := strings.Index(kv, "="); i > 0 {
+				vals[kv[:i]] = kv[i+1:]
+			}
+		}
+		l.useEnv = true
+		l.lookupEnv = func(k string) (string, bool) {
+			v, ok := vals[k]
+			return v, ok
+		}
+		return nil
+	}
+}
+
+// Flags registers a flag for every field on fs and parses args with it
+func Flags(fs *flag.FlagSet, args []string) Option {
+	return func(l *loader) error {
+		if fs == nil {
+			return errors.New("config: nil FlagSet")
+		}
+		l.flags = fs
+		l.flagArgs = args
+		return nil
+	}
+}
+
+// Load fills dst, which needs to be a pointer to a struct, from all sources.
+// Invalid values are returned as Errors.
+func Load(dst interface{}, opts ...Option) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("config: need a pointer to a struct, got %T", dst)
+	}
+
+	l := loader{lookupEnv: os.LookupEnv}
+	for i, o := range opts {
+		if err := o(&l); err != nil {
+			return errors.Wrapf(err, "config: option %d failed", i)
+		}
+	}
+
+	fields, err := collectFields(rv.Elem(), nil)
+	if err != nil {
+		return err
+	}
+
+	var errs Errors
+	set := func(f *field, src, val string) {
+		if err := f.set(val); err != nil {
+			errs = append(errs, &FieldError{Field: f.key(), Source: src, Value: val, Err: err})
+			return
+		}
+		f.isSet = true
+	}
+
+	for _, f := range fields {
+		if def, ok := f.tag.Lookup("default"); ok {
+			set(f, "default", def)
+		}
+	}
+
+	if l.file != "" {
+		ferrs, err := l.loadFile(fields)
+		if err != nil {
+			return err
+		}
+		errs = append(errs, ferrs...)
+	}
+
+	if l.useEnv {
+		for _, f := range fields {
+			name := f.envName(l.envPrefix)
+			if v, ok := l.lookupEnv(name); ok {
+				set(f, "env "+name, v)
+			}
+		}
+	}
+
+	if l.flags != nil {
+		for _, f := range fields {
+			l.flags.Var(&flagValue{f: f, errs: &errs}, f.flagName(), f.tag.Get("usage"))
+		}
+		if err := l.flags.Parse(l.flagArgs); err != nil {
+			if len(errs) > 0 {
+				return errs
+			}
+			return errors.Wrap(err, "config: failed to parse flags")
+		}
+	}
+
+	for _, f := range fields {
+		if f.tag.Get("required") == "true" && !f.isSet {
+			errs = append(errs, &FieldError{Field: f.key(), Source: "required"})
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	if v, ok := dst.(Validator); ok {
+		if err := v.Validate(); err != nil {
+			return errors.Wrap(err, "config: validation failed")
+		}
+	}
+	return nil
+}
+
+// flagValue sets a field from a command-line flag
+type flagValue struct {
+	f    *field
+	errs *Errors
+}
+
+func (fv *flagValue) String() string {
+	if fv == nil || fv.f == nil {
+		return ""
+	}
+	return fv.f.String()
+}
+
+func (fv *flagValue) Set(s string) error {
+	if err := fv.f.set(s); err != nil {
+		*fv.errs = append(*fv.errs, &FieldError{Field: fv.f.key(), Source: "flag -" + fv.f.flagName(), Value: s, Err: err})
+		return err
+	}
+	fv.f.isSet = true
+	return nil
+}
+
+// IsBoolFlag allows -debug instead of -debug=true
+func (fv *flagValue) IsBoolFlag() bool {
+	return fv.f.v.Kind() == reflect.Bool
+}
+
+type field struct {
+	path  []string
+	v     reflect.Value
+	tag   reflect.StructTag
+	isSet bool
+}
+
+func (f *field) key() string { return strings.Join(f.path, ".") }
+
+func (f *field) flagName() string {
+	if name := f.tag.Get("flag"); name != "" {
+		return name
+	}
+	return strings.Replace(f.key(), "_", "-", -1)
+}
+
+func (f *field) envName(prefix string) string {
+	if name := f.tag.Get("env"); name != "" {
+		return name
+	}
+	name := strings.ToUpper(strings.Join(f.path, "_"))
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+	return name
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+func collectFields(v reflect.Value, prefix []string) ([]*field, error) {
+	var fields []*field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" { // unexported
+			continue
+		}
+		name := sf.Tag.Get("config")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = snakeCase(sf.Name)
+		}
+		path := append(append([]string(nil), prefix...), name)
+
+		fv := v.Field(i)
+		if fv.Kind() == reflect.Struct && !reflect.PtrTo(fv.Type()).Implements(textUnmarshalerType) {
+			nested, err := collectFields(fv, path)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, nested...)
+			continue
+		}
+
+		f := &field{path: path, v: fv, tag: sf.Tag}
+		if !f.supported() {
+			return nil, errors.Errorf("config: unsupported type %s of field %s", fv.Type(), f.key())
+		}
+		fields = append(fields, f)
+	}
+	return fields, nil
+}
+
+func (f *field) supported() bool {
+	if reflect.PtrTo(f.v.Type()).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch f.v.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Slice:
+		return f.v.Type().Elem().Kind() == reflect.String
+	}
+	return false
+}
+
+// set parses s into the field
+func (f *field) set(s string) error {
+	if u, ok := f.v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	if f.v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		f.v.SetInt(int64(d))
+		return nil
+	}
+
+	switch f.v.Kind() {
+	case reflect.String:
+		f.v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		f.v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, f.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, f.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(s, f.v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		f.v.SetFloat(n)
+	case reflect.Slice:
+		var parts []string
+		if s != "" {
+			parts = strings.Split(s, ",")
+			for i := range parts {
+				parts[i] = strings.TrimSpace(parts[i])
+			}
+		}
+		return f.setStrings(parts)
+	}
+	return nil
+}
+
+func (f *field) setStrings(vals []string) error {
+	sl := reflect.MakeSlice(f.v.Type(), len(vals), len(vals))
+	for i, s := range vals {
+		sl.Index(i).SetString(s)
+	}
+	f.v.Set(sl)
+	return nil
+}
+
+// String returns the current value of the field in the form set accepts
+func (f *field) String() string {
+	if f.v.Type().Implements(textMarshalerType) {
+		out, err := f.v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return err.Error()
+		}
+		return string(out)
+	}
+	if f.v.Type() == durationType {
+		return time.Duration(f.v.Int()).String()
+	}
+	if f.v.Kind() == reflect.Slice {
+		// the elements may have a named string type
+		parts := make([]string, f.v.Len())
+		for i := range parts {
+			parts[i] = f.v.Index(i).String()
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(f.v.Interface())
+}
+
+// snakeCase converts MaxConns to max_conns and HTTPAddr to http_addr
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if i > 0 && (prevLower || nextLower) && runes[i-1] != '_' {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
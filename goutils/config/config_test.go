@@ -0,0 +1,213 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"io/ioutil"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testConfig struct {
+	Listen string   `default:":8080" usage:"address to listen on"`
+	Tags   []string `config:"tags"`
+	Debug  bool
+	IP     net.IP `config:"ip" default:"127.0.0.1"`
+
+	DB struct {
+		URL      string        `config:"url" required:"true"`
+		MaxConns int           `default:"4"`
+		Timeout  time.Duration `default:"30s"`
+	} `config:"db"`
+
+	Internal string `config:"-"`
+	hidden   string
+}
+
+func (c *testConfig) Validate() error {
+	if c.DB.MaxConns > 100 {
+		return errors.New("too many connections")
+	}
+	return nil
+}
+
+func TestDefaults(t *testing.T) {
+	var c testConfig
+	err := Load(&c, Environ([]string{"DB_URL=postgres://db"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Listen != ":8080" || c.DB.MaxConns != 4 || c.DB.Timeout != 30*time.Second || !c.IP.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("defaults not applied: %+v", c)
+	}
+	if c.DB.URL != "postgres://db" {
+		t.Errorf("env not applied: %+v", c)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	for _, file := range []string{"testdata/app.toml", "testdata/app.json"} {
+		var c testConfig
+		if err := Load(&c, File(file)); err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+		if c.Listen != ":9090" || c.DB.URL != "postgres://localhost/app" || c.DB.MaxConns != 10 || c.DB.Timeout != 5*time.Second {
+			t.Errorf("%s: wrong values: %+v", file, c)
+		}
+		if !reflect.DeepEqual(c.Tags, []string{"a", "b"}) {
+			t.Errorf("%s: wrong tags: %v", file, c.Tags)
+		}
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	var c testConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	err := Load(&c,
+		File("testdata/app.toml"),
+		Env("APP"),
+		Environ([]string{"APP_LISTEN=:7070", "APP_DB_MAX_CONNS=20", "APP_TAGS=x, y"}),
+		Flags(fs, []string{"-listen", ":6060", "-debug", "-db.timeout=1m"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Listen != ":6060" {
+		t.Errorf("flag should win: %s", c.Listen)
+	}
+	if c.DB.MaxConns != 20 {
+		t.Errorf("env should win over file: %d", c.DB.MaxConns)
+	}
+	if c.DB.URL != "postgres://localhost/app" {
+		t.Errorf("file should win over default: %s", c.DB.URL)
+	}
+	if !c.Debug || c.DB.Timeout != time.Minute {
+		t.Errorf("flags not applied: %+v", c)
+	}
+	if !reflect.DeepEqual(c.Tags, []string{"x", "y"}) {
+		t.Errorf("wrong tags: %v", c.Tags)
+	}
+
+	if f := fs.Lookup("db.max-conns"); f == nil || f.Usage != "" {
+		t.Errorf("missing flag for nested field")
+	}
+	if f := fs.Lookup("listen"); f == nil || f.Usage != "address to listen on" {
+		t.Errorf("missing usage")
+	}
+	if fs.Lookup("internal") != nil || fs.Lookup("hidden") != nil {
+		t.Errorf("skipped fields have flags")
+	}
+}
+
+func TestErrorSources(t *testing.T) {
+	var c testConfig
+	err := Load(&c,
+		File("testdata/bad.json"),
+		Env("APP"),
+		Environ([]string{"APP_DEBUG=maybe"}),
+	)
+
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("expected Errors, got %v", err)
+	}
+
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	want := []string{
+		`config: invalid value "many" for db.max_conns from file testdata/bad.json: strconv.ParseInt: parsing "many": invalid syntax`,
+		`config: invalid value "5s" for db.timout from file testdata/bad.json: unknown key`,
+		`config: invalid value "maybe" for debug from env APP_DEBUG: strconv.ParseBool: parsing "maybe": invalid syntax`,
+		`config: db.url is required`,
+	}
+	if !reflect.DeepEqual(msgs, want) {
+		t.Errorf("wrong errors:\n%s", strings.Join(msgs, "\n"))
+	}
+}
+
+func TestDecimalIntegers(t *testing.T) {
+	var c testConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	err := Load(&c, Environ([]string{"DB_URL=x", "DB_MAX_CONNS=010"}), Flags(fs, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DB.MaxConns != 10 {
+		t.Errorf("leading zero parsed as octal: %d", c.DB.MaxConns)
+	}
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	if err := Load(&c, Environ([]string{"DB_URL=x"}), Flags(fs, []string{"-db.max-conns=0x10"})); err == nil {
+		t.Error("expected error for a hexadecimal flag")
+	}
+}
+
+func TestFlagError(t *testing.T) {
+	var c testConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	err := Load(&c, Environ([]string{"DB_URL=x"}), Flags(fs, []string{"-ip", "localhost"}))
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs[0].Source != "flag -ip" {
+		t.Fatalf("expected error from flag, got %v", err)
+	}
+}
+
+func TestNamedStringSlice(t *testing.T) {
+	type Host string
+	var c struct {
+		Hosts []Host `default:"a.example,b.example"`
+	}
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if err := Load(&c, Flags(fs, []string{"-hosts", "c.example, d.example"})); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(c.Hosts, []Host{"c.example", "d.example"}) {
+		t.Errorf("wrong hosts: %v", c.Hosts)
+	}
+	if f := fs.Lookup("hosts"); f == nil || f.DefValue != "a.example,b.example" {
+		t.Errorf("wrong flag default: %+v", f)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var c testConfig
+	err := Load(&c, Environ([]string{"DB_URL=x", "DB_MAX_CONNS=1000"}))
+	if err == nil || !strings.Contains(err.Error(), "too many connections") {
+		t.Fatalf("expected validation error, got %v", err)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	var c struct {
+		M map[string]string
+	}
+	if err := Load(&c); err == nil {
+		t.Fatal("expected error for map field")
+	}
+	if err := Load(c); err == nil {
+		t.Fatal("expected error for non-pointer")
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"Listen":   "listen",
+		"MaxConns": "max_conns",
+		"HTTPAddr": "http_addr",
+		"DB":       "db",
+		"UserID":   "user_id",
+	} {
+		if got := snakeCase(in); got != want {
+			t.Errorf("%s: got %s, want %s", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+)
+
+// loadFile applies the values of the config file to fields. Unknown keys are reported as errors.
+func (l *loader) loadFile(fields []*field) (Errors, error) {
+	data, err := ioutil.ReadFile(l.file)
+	if err != nil {
+		return nil, errors.Wrap(err, "config: failed to read file")
+	}
+
+	var tree map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(l.file)); ext {
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.UseNumber()
+		if err := dec.Decode(&tree); err != nil {
+			return nil, errors.Wrapf(err, "config: failed to decode %s", l.file)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, &tree); err != nil {
+			return nil, errors.Wrapf(err, "config: failed to decode %s", l.file)
+		}
+	default:
+		return nil, errors.Errorf("config: unsupported file format %q", ext)
+	}
+
+	flat := make(map[string]interface{})
+	flatten(flat, "", tree)
+
+	src := "file " + l.file
+	var errs Errors
+	for _, f := range fields {
+		v, ok := flat[f.key()]
+		if !ok {
+			continue
+		}
+		delete(flat, f.key())
+
+		if f.v.Kind() == reflect.Slice && !reflect.PtrTo(f.v.Type()).Implements(textUnmarshalerType) {
+			if err := f.setList(v); err != nil {
+				errs = append(errs, &FieldError{Field: f.key(), Source: src, Value: fmt.Sprint(v), Err: err})
+				continue
+			}
+			f.isSet = true
+			continue
+		}
+
+		s, err := scalarString(v)
+		if err == nil {
+			err = f.set(s)
+		}
+		if err != nil {
+			errs = append(errs, &FieldError{Field: f.key(), Source: src, Value: fmt.Sprint(v), Err: err})
+			continue
+		}
+		f.isSet = true
+	}
+
+	unknown := make([]string, 0, len(flat))
+	for k := range flat {
+		unknown = append(unknown, k)
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		errs = append(errs, &FieldError{Field: k, Source: src, Value: fmt.Sprint(flat[k]), Err: errors.New("unknown key")})
+	}
+	return errs, nil
+}
+
+// flatten turns nested maps into dotted keys
+func flatten(dst map[string]interface{}, prefix string, tree map[string]interface{}) {
+	for k, v := range tree {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]interface{}); ok {
+			flatten(dst, k, sub)
+			continue
+		}
+		dst[k] = v
+	}
+}
+
+func (f *field) setList(v interface{}) error {
+	list, ok := v.([]interface{})
+	if !ok {
+		return errors.New("expected a list")
+	}
+	vals := make([]string, len(list))
+	for i, e := range list {
+		s, err := scalarString(e)
+		if err != nil {
+			return errors.Wrapf(err, "element %d", i)
+		}
+		vals[i] = s
+	}
+	return f.setStrings(vals)
+}
+
+// scalarString converts a decoded file value into the text form field.set understands
+func scalarString(v interface{}) (string, error) {
+	switch x := v.(type) {
+	case string:
+		return x, nil
+	case json.Number:
+		return x.String(), nil
+	case bool:
+		return strconv.FormatBool(x), nil
+	case int64:
+		return strconv.FormatInt(x, 10), nil
+	case float64:
+		return strconv.FormatFloat(x, 'g', -1, 64), nil
+	case time.Time:
+		// the TOML decoder marks local dates and times with these zones
+		switch x.Location().String() {
+		case "datetime-local":
+			return x.Format("2006-01-02T15:04:05.999999999"), nil
+		case "date-local":
+			return x.Format("2006-01-02"), nil
+		case "time-local":
+			return x.Format("15:04:05.999999999"), nil
+		}
+		return x.Format(time.RFC3339Nano), nil
+	case nil:
+		return "", errors.New("null is not a value")
+	}
+	return "", errors.Errorf("expected a single value, got %T", v)
+}
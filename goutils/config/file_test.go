@@ -0,0 +1,76 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTOML(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "config-toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	p := filepath.Join(dir, "config.toml")
+	if err := ioutil.WriteFile(p, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestTOMLFeatures(t *testing.T) {
+	var c struct {
+		Title   string
+		Mask    int
+		Started time.Time
+		Day     string
+		At      string
+		DB      struct {
+			URL      string `config:"url"`
+			MaxConns int
+		} `config:"db"`
+	}
+	p := writeTOML(t, `
+title = """
+multi
+line"""
+mask = 0o755
+started = 1979-05-27T07:32:00Z
+day = 1979-05-27
+at = 07:32:00
+db = { url = "postgres://localhost/app", max_conns = 4 }
+`)
+	if err := Load(&c, File(p)); err != nil {
+		t.Fatal(err)
+	}
+	if c.Title != "multi\nline" || c.Mask != 0755 || c.Day != "1979-05-27" || c.At != "07:32:00" {
+		t.Errorf("wrong values: %+v", c)
+	}
+	if !c.Started.Equal(time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)) {
+		t.Errorf("wrong time: %s", c.Started)
+	}
+	if c.DB.URL != "postgres://localhost/app" || c.DB.MaxConns != 4 {
+		t.Errorf("inline table not applied: %+v", c.DB)
+	}
+}
+
+func TestTOMLErrors(t *testing.T) {
+	for _, in := range []string{
+		`a = abcd-efgh`,
+		`b = 010`,
+		"[db]\nurl = \"x\"\n[db]\nurl = \"y\"",
+	} {
+		var c struct {
+			A, B string
+			DB   struct{ URL string }
+		}
+		err := Load(&c, File(writeTOML(t, in)))
+		if err == nil || !strings.Contains(err.Error(), "failed to decode") {
+			t.Errorf("%q: expected decode error, got %v", in, err)
+		}
+	}
+}
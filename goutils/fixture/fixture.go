@@ -0,0 +1,87 @@
+/*
+Package fixture helps tests with their test data and golden files.
+
+Everything is relative to the testdata directory of a package, which is where `go test` expects it.
+Golden files are compared byte by byte. Running the tests with -fixture.update rewrites them with the current output instead:
+
+	go test ./http/render -fixture.update
+
+The flag is namespaced, so test packages are free to define their own -update flag.
+*/
+package fixture
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.mindeco.de/goutils"
+)
+
+var update = flag.Bool("fixture.update", false, "rewrite golden files with the current output")
+
+// Updating reports whether the tests run with -fixture.update
+func Updating() bool {
+	return *update
+}
+
+// Dir returns the testdata directory of the package under test
+func Dir(t testing.TB) string {
+	t.Helper()
+	return "testdata"
+}
+
+// PackageDir returns the testdata directory of the package with importPath, relative to the working directory
+func PackageDir(t testing.TB, importPath string) string {
+	t.Helper()
+	dir, err := goutils.LocatePackage(importPath)
+	if err != nil {
+		t.Fatalf("fixture: %+v", err)
+	}
+	return filepath.Join(dir, "testdata")
+}
+
+// Path returns the path of name inside the testdata directory of the package under test
+func Path(t testing.TB, name string) string {
+	t.Helper()
+	return filepath.Join(Dir(t), filepath.FromSlash(name))
+}
+
+// Load returns the contents of name inside the testdata directory of the package under test
+func Load(t testing.TB, name string) []byte {
+	t.Helper()
+	b, err := ioutil.ReadFile(Path(t, name))
+	if err != nil {
+		t.Fatalf("fixture: failed to load: %s", err)
+	}
+	return b
+}
+
+// Golden compares got against testdata/<name>.golden and fails the test with a diff if they differ.
+// With -fixture.update the golden file is (re)written instead.
+func Golden(t testing.TB, name string, got []byte) {
+	t.Helper()
+	p := Path(t, name+".golden")
+
+	if Updating() {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatalf("fixture: failed to create golden dir: %s", err)
+		}
+		if err := ioutil.WriteFile(p, got, 0644); err != nil {
+			t.Fatalf("fixture: failed to update golden file: %s", err)
+		}
+		t.Logf("fixture: updated %s", p)
+		return
+	}
+
+	want, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatalf("fixture: failed to read golden file (run with -fixture.update to create it): %s", err)
+	}
+	if !bytes.Equal(want, got) {
+		t.Errorf("fixture: output differs from %s (run with -fixture.update to accept it):\n%s", p, Diff(string(want), string(got)))
+	}
+}
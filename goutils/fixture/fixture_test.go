@@ -0,0 +1,78 @@
+package fixture
+
+import (
+	"flag"
+	"testing"
+)
+
+// a package under test may have its own -update flag next to the one of fixture
+var ownUpdate = flag.Bool("update", false, "an unrelated update flag")
+
+func TestLoad(t *testing.T) {
+	if got := string(Load(t, "hello.txt")); got != "hello, world\n" {
+		t.Fatalf("wrong content: %q", got)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	Golden(t, "hello", []byte("hello, world\n"))
+}
+
+func TestUpdating(t *testing.T) {
+	if Updating() {
+		t.Skip("running with -fixture.update")
+	}
+	if err := flag.Set("update", "true"); err != nil {
+		t.Fatal(err)
+	}
+	defer flag.Set("update", "false")
+	if Updating() {
+		t.Error("-update shouldn't enable fixture updates")
+	}
+	if !*ownUpdate {
+		t.Error("own flag not set")
+	}
+}
+
+func TestPackageDir(t *testing.T) {
+	if got := PackageDir(t, "go.mindeco.de/goutils/fixture"); got != "testdata" {
+		t.Fatalf("wrong dir: %s", got)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	if d := Diff("a\nb\n", "a\nb\n"); d != "" {
+		t.Fatalf("expected no diff: %q", d)
+	}
+
+	want := "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n"
+	got := "1\n2\n3\n4\nfive\n6\n7\n8\n9\n10\neleven\n"
+	expected := `--- want
++++ got
+@@ -2 +2 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+ 9
+ 10
++eleven
+`
+	if d := Diff(want, got); d != expected {
+		t.Fatalf("wrong diff:\n%s", d)
+	}
+
+	expected = `--- want
++++ got
+@@ -1 +1 @@
+-a
++a (no newline at end)
+`
+	if d := Diff("a\n", "a"); d != expected {
+		t.Fatalf("wrong diff:\n%s", d)
+	}
+}
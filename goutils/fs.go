@@ -0,0 +1,135 @@
+package goutils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// WriteFileAtomic writes data to a temporary file next to path, syncs it and renames it over path.
+// Readers either see the old or the new contents, never a partial write.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) error {
+	f, err := NewAtomicFile(path, perm)
+	if err != nil {
+		return errors.Wrap(err, "WriteFileAtomic: failed to create")
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Abort()
+		return errors.Wrap(err, "WriteFileAtomic: failed to write")
+	}
+
+	return errors.Wrap(f.Close(), "WriteFileAtomic: failed to commit")
+}
+
+// AtomicFile is a temporary file that replaces the file at its target path when it's closed.
+// Use Abort to discard it instead.
+type AtomicFile struct {
+	*os.File
+
+	path   string
+	closed bool
+}
+
+// NewAtomicFile creates a temporary file in the directory of path, which is renamed to path by Close
+func NewAtomicFile(path string, perm os.FileMode) (*AtomicFile, error) {
+	dir, base := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+
+	f, err := ioutil.TempFile(dir, "."+base+".tmp")
+	if err != nil {
+		return nil, errors.Wrap(err, "NewAtomicFile: failed to create temp file")
+	}
+
+	if err := f.Chmod(perm); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, errors.Wrap(err, "NewAtomicFile: failed to set permissions")
+	}
+
+	return &AtomicFile{File: f, path: path}, nil
+}
+
+// Close syncs the temporary file to disk and renames it to the target path.
+// On failure the temporary file is removed and the target is left untouched.
+func (af *AtomicFile) Close() error {
+	if af.closed {
+		return errors.New("AtomicFile: already closed")
+	}
+	af.closed = true
+
+	tmp := af.File.Name()
+	if err := af.File.Sync(); err != nil {
+		af.File.Close()
+		os.Remove(tmp)
+		return errors.Wrap(err, "AtomicFile: failed to sync")
+	}
+	if err := af.File.Close(); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "AtomicFile: failed to close")
+	}
+	if err := os.Rename(tmp, af.path); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "AtomicFile: failed to rename")
+	}
+
+	return errors.Wrap(syncDir(filepath.Dir(af.path)), "AtomicFile: failed to sync directory")
+}
+
+// Abort discards the temporary file. It is a no-op after Close.
+func (af *AtomicFile) Abort() error {
+	if af.closed {
+		return nil
+	}
+	af.closed = true
+
+	af.File.Close()
+	return errors.Wrap(os.Remove(af.File.Name()), "AtomicFile: failed to remove temp file")
+}
+
+// syncDir makes a rename inside dir durable. Not all platforms support this, so errors from Sync are ignored.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	d.Sync()
+	return d.Close()
+}
+
+// EnsureDir creates path and its parents with perm, if needed.
+// Existing directories are never changed: one with more permissions than perm is an error,
+// so is anything but a directory.
+func EnsureDir(path string, perm os.FileMode) error {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		if err := os.MkdirAll(path, perm); err != nil {
+			return errors.Wrap(err, "EnsureDir: failed to create")
+		}
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "EnsureDir: failed to stat")
+	}
+	if !fi.IsDir() {
+		return errors.Errorf("EnsureDir: %s is not a directory", path)
+	}
+
+	if fi.Mode().Perm()&^perm != 0 {
+		return errors.Errorf("EnsureDir: %s has permissions %s, more than %s", path, fi.Mode().Perm(), perm)
+	}
+	return nil
+}
+
+// TempDir creates a new temporary directory and returns it together with a function that removes it
+func TempDir(prefix string) (string, func(), error) {
+	dir, err := ioutil.TempDir("", prefix)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "TempDir: failed to create")
+	}
+	return dir, func() { os.RemoveAll(dir) }, nil
+}
@@ -0,0 +1,157 @@
+package goutils
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir, cleanup, err := TempDir("goutils-fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	p := filepath.Join(dir, "state.json")
+	for _, content := range []string{"first", "second"} {
+		if err := WriteFileAtomic(p, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ioutil.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != content {
+			t.Fatalf("wrong content: %q", got)
+		}
+	}
+
+	if runtime.GOOS != "windows" {
+		fi, err := os.Stat(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fi.Mode().Perm() != 0600 {
+			t.Errorf("wrong permissions: %s", fi.Mode())
+		}
+	}
+
+	// aborted writes leave the old file and no temporary files behind
+	af, err := NewAtomicFile(p, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	af.Write([]byte("partial"))
+	if err := af.Abort(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "second" {
+		t.Fatalf("wrong content after abort: %q", got)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only the target file, got %d entries", len(entries))
+	}
+
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "x"), nil, 0600); err == nil {
+		t.Fatal("expected error for missing directory")
+	}
+
+	cleanup()
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatal("cleanup did not remove the directory")
+	}
+}
+
+func TestEnsureDir(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no unix permissions")
+	}
+	dir, cleanup, err := TempDir("goutils-fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	p := filepath.Join(dir, "a", "b")
+	if err := EnsureDir(p, 0700); err != nil {
+		t.Fatal(err)
+	}
+
+	fi, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm()&^0700 != 0 {
+		t.Errorf("created with wrong permissions: %s", fi.Mode())
+	}
+
+	// existing directories are checked, not changed
+	if err := os.Chmod(p, 0500); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureDir(p, 0700); err != nil {
+		t.Errorf("stricter directory rejected: %s", err)
+	}
+	if err := os.Chmod(p, 0777|os.ModeSticky); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureDir(p, 0755); err == nil {
+		t.Error("expected error for a directory with more permissions")
+	}
+	if fi, err = os.Stat(p); err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0777 || fi.Mode()&os.ModeSticky == 0 {
+		t.Errorf("existing directory was changed: %s", fi.Mode())
+	}
+
+	f := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(f, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsureDir(f, 0700); err == nil {
+		t.Error("expected error for a file")
+	}
+}
+
+func TestLockFile(t *testing.T) {
+	dir, cleanup, err := TempDir("goutils-fs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	p := filepath.Join(dir, "lock")
+	l, err := LockFile(p)
+	if err != nil {
+		t.Skip("locking not supported:", err)
+	}
+
+	if _, err := TryLockFile(p); err != ErrLocked {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+
+	if err := l.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	l2, err := TryLockFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l2.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+}
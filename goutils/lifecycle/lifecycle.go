@@ -0,0 +1,242 @@
+/*
+Package lifecycle starts and stops the components of an application in order.
+
+Components are registered as Hooks. Run starts them in the order they were appended,
+waits for SIGINT/SIGTERM, a canceled context or a call to Shutdown and then stops them in reverse order.
+It also registers itself with logging.SetCloseChan, so that logging.CheckFatal triggers the same clean shutdown
+before the process exits. The previous close channel is restored once Run returns.
+*/
+package lifecycle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+	"go.mindeco.de/logging"
+)
+
+// Hook is a component with functions to start and stop it. Both are optional.
+type Hook struct {
+	Name string
+
+	OnStart func(context.Context) error
+	OnStop  func(context.Context) error
+
+	// Timeout overwrites the start and stop timeout of the Manager for this hook
+	Timeout time.Duration
+}
+
+// Manager runs the hooks of an application
+type Manager struct {
+	log kitlog.Logger
+
+	startTimeout time.Duration
+	stopTimeout  time.Duration
+	signals      []os.Signal
+
+	mu      sync.Mutex
+	hooks   []Hook
+	started int // number of hooks that were started successfully
+	running bool
+
+	shutdownOnce sync.Once
+	shutdown     chan struct{}
+	done         chan struct{}
+}
+
+// New creates a Manager. By default hooks have 15 seconds to start, all hooks have 30 seconds to stop
+// and Run listens for SIGINT and SIGTERM.
+func New(opts ...Option) (*Manager, error) {
+	m := &Manager{
+		shutdown: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	for i, o := range opts {
+		if err := o(m); err != nil {
+			return nil, errors.Wrapf(err, "lifecycle: option %d failed", i)
+		}
+	}
+
+	if m.log == nil {
+		m.log = logging.Logger("lifecycle")
+	}
+
+	if m.startTimeout == 0 {
+		m.startTimeout = 15 * time.Second
+	}
+
+	if m.stopTimeout == 0 {
+		m.stopTimeout = 30 * time.Second
+	}
+
+	if m.signals == nil {
+		m.signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	return m, nil
+}
+
+// Append registers a hook. Hooks are started in the order they were appended and stopped in reverse.
+func (m *Manager) Append(h Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if h.Name == "" {
+		h.Name = fmt.Sprintf("hook%d", len(m.hooks))
+	}
+	m.hooks = append(m.hooks, h)
+}
+
+// Start calls OnStart of all hooks in order. If one of them fails, the hooks that were already started are stopped again.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := m.started; i < len(m.hooks); i++ {
+		h := m.hooks[i]
+		start := time.Now()
+		if err := m.call(ctx, h, h.OnStart, m.startTimeout); err != nil {
+			m.log.Log("event", "start failed", "hook", h.Name, "err", err)
+
+			stopCtx, cancel := context.WithTimeout(context.Background(), m.stopTimeout)
+			defer cancel()
+			if stopErr := m.stop(stopCtx); stopErr != nil {
+				m.log.Log("event", "rollback failed", "err", stopErr)
+			}
+			return errors.Wrapf(err, "lifecycle: failed to start %s", h.Name)
+		}
+		m.started = i + 1
+		m.log.Log("event", "started", "hook", h.Name, "took", time.Since(start))
+	}
+	return nil
+}
+
+// Stop calls OnStop of the started hooks in reverse order. Failing hooks don't keep the others from stopping.
+func (m *Manager) Stop(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stop(ctx)
+}
+
+func (m *Manager) stop(ctx context.Context) error {
+	var failed []string
+	for i := m.started - 1; i >= 0; i-- {
+		h := m.hooks[i]
+		start := time.Now()
+		if err := m.call(ctx, h, h.OnStop, 0); err != nil {
+			m.log.Log("event", "stop failed", "hook", h.Name, "err", err)
+			failed = append(failed, h.Name)
+		} else {
+			m.log.Log("event", "stopped", "hook", h.Name, "took", time.Since(start))
+		}
+		m.started = i
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("lifecycle: failed to stop %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// call runs fn with a timeout. If fn doesn't return in time, it's left running and an error is returned.
+// A timeout of zero only uses the hook's own timeout, if any, on top of ctx.
+func (m *Manager) call(ctx context.Context, h Hook, fn func(context.Context) error, timeout time.Duration) error {
+	if fn == nil {
+		return nil
+	}
+	if h.Timeout != 0 {
+		timeout = h.Timeout
+	}
+	if timeout != 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- fn(ctx)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "hook did not return in time")
+	}
+}
+
+// Shutdown makes Run stop the hooks and return. It can be called multiple times.
+func (m *Manager) Shutdown() {
+	m.shutdownOnce.Do(func() { close(m.shutdown) })
+}
+
+// Done is closed once Run stopped all hooks
+func (m *Manager) Done() <-chan struct{} {
+	return m.done
+}
+
+// Run starts all hooks and blocks until a signal arrives, ctx is canceled, Shutdown is called or
+// logging.CheckFatal is used. Then it stops all hooks and returns. It can only be called once.
+func (m *Manager) Run(ctx context.Context) error {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return errors.New("lifecycle: Run called twice")
+	}
+	m.running = true
+	m.mu.Unlock()
+	defer close(m.done)
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, m.signals...)
+	defer signal.Stop(sigs)
+
+	// CheckFatal sends on this channel and waits for m.done before it exits the process
+	fatal := make(chan os.Signal)
+	stopFatal := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-fatal:
+				m.log.Log("event", "fatal error, shutting down")
+				m.Shutdown()
+			case <-stopFatal:
+				return
+			}
+		}
+	}()
+	prevChan := logging.CloseChan()
+	prevWait, prevTimeout := logging.CloseWait()
+	logging.SetCloseChan(fatal)
+	logging.SetCloseWait(m.done, m.stopTimeout+time.Second)
+	defer func() {
+		logging.SetCloseChan(prevChan)
+		logging.SetCloseWait(prevWait, prevTimeout)
+		close(stopFatal)
+	}()
+
+	if err := m.Start(ctx); err != nil {
+		return err
+	}
+
+	select {
+	case s := <-sigs:
+		m.log.Log("event", "shutting down", "signal", s)
+	case <-ctx.Done():
+		m.log.Log("event", "shutting down", "reason", ctx.Err())
+	case <-m.shutdown:
+		m.log.Log("event", "shutting down", "reason", "shutdown requested")
+	}
+
+	stopCtx, cancel := context.WithTimeout(context.Background(), m.stopTimeout)
+	defer cancel()
+	return m.Stop(stopCtx)
+}
@@ -0,0 +1,266 @@
+package lifecycle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+
+	"go.mindeco.de/logging"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) hook(name string, startErr error) Hook {
+	return Hook{
+		Name: name,
+		OnStart: func(context.Context) error {
+			r.add("start " + name)
+			return startErr
+		},
+		OnStop: func(context.Context) error {
+			r.add("stop " + name)
+			return nil
+		},
+	}
+}
+
+func (r *recorder) add(ev string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, ev)
+}
+
+func (r *recorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func newTestManager(t *testing.T, opts ...Option) *Manager {
+	// log synchronously, the async logtest logger can write after the test completed
+	var buf bytes.Buffer
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Log(buf.String())
+		}
+	})
+	l := kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(&buf))
+	m, err := New(append([]Option{SetLogger(l)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestOrder(t *testing.T) {
+	var rec recorder
+	m := newTestManager(t)
+	m.Append(rec.hook("db", nil))
+	m.Append(rec.hook("http", nil))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"start db", "start http", "stop http", "stop db"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong order: %v", got)
+	}
+}
+
+func TestStartRollback(t *testing.T) {
+	var rec recorder
+	m := newTestManager(t)
+	m.Append(rec.hook("db", nil))
+	m.Append(rec.hook("cache", errors.New("no connection")))
+	m.Append(rec.hook("http", nil))
+
+	err := m.Start(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "cache") {
+		t.Fatalf("expected start error, got %v", err)
+	}
+
+	want := []string{"start db", "start cache", "stop db"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong order: %v", got)
+	}
+}
+
+func TestStopTimeout(t *testing.T) {
+	var rec recorder
+	m := newTestManager(t)
+	m.Append(rec.hook("db", nil))
+	m.Append(Hook{
+		Name:    "stuck",
+		Timeout: 10 * time.Millisecond,
+		OnStop: func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err := m.Stop(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "stuck") {
+		t.Fatalf("expected stop error, got %v", err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("Stop waited for the stuck hook")
+	}
+
+	// the other hooks are still stopped
+	want := []string{"start db", "stop db"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong order: %v", got)
+	}
+}
+
+func TestRunShutdown(t *testing.T) {
+	var rec recorder
+	m := newTestManager(t)
+	m.Append(rec.hook("db", nil))
+	m.Append(Hook{
+		Name: "trigger",
+		OnStart: func(context.Context) error {
+			go m.Shutdown()
+			return nil
+		},
+	})
+
+	if err := m.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-m.Done():
+	default:
+		t.Fatal("done not closed")
+	}
+
+	want := []string{"start db", "stop db"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong order: %v", got)
+	}
+
+	if err := m.Run(context.Background()); err == nil {
+		t.Fatal("expected error for second Run")
+	}
+}
+
+func TestRunContext(t *testing.T) {
+	var rec recorder
+	m := newTestManager(t)
+	m.Append(rec.hook("db", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"start db", "stop db"}
+	if got := rec.get(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("wrong order: %v", got)
+	}
+}
+
+func TestRunRestoresCloseChan(t *testing.T) {
+	prev := make(chan os.Signal, 1)
+	prevDone := make(chan struct{})
+	logging.SetCloseChan(prev)
+	logging.SetCloseWait(prevDone, time.Minute)
+	defer logging.SetCloseChan(nil)
+	defer logging.SetCloseWait(nil, 0)
+
+	before := runtime.NumGoroutine()
+	for i := 0; i < 10; i++ {
+		m, err := New(SetLogger(kitlog.NewNopLogger()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := m.Run(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if logging.CloseChan() != chan<- os.Signal(prev) {
+		t.Error("close chan not restored")
+	}
+	if done, timeout := logging.CloseWait(); done != (<-chan struct{})(prevDone) || timeout != time.Minute {
+		t.Error("close wait not restored")
+	}
+
+	// give the stopped goroutines a moment to exit
+	for i := 0; i < 100 && runtime.NumGoroutine() > before; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("leaked %d goroutines", n-before)
+	}
+}
+
+// TestCheckFatal runs itself as a subprocess, because CheckFatal exits the process
+func TestCheckFatal(t *testing.T) {
+	if os.Getenv("LIFECYCLE_FATAL_HELPER") == "1" {
+		m, err := New(SetStopTimeout(time.Second))
+		if err != nil {
+			panic(err)
+		}
+		m.Append(Hook{
+			Name: "worker",
+			OnStart: func(context.Context) error {
+				go logging.CheckFatal(errors.New("something broke"))
+				return nil
+			},
+			OnStop: func(context.Context) error {
+				fmt.Println("worker stopped cleanly")
+				return nil
+			},
+		})
+		m.Run(context.Background())
+		time.Sleep(5 * time.Second) // CheckFatal should exit before this
+		os.Exit(0)
+	}
+
+	dir, err := ioutil.TempDir("", "lifecycle-fatal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestCheckFatal$")
+	cmd.Env = append(os.Environ(), "LIFECYCLE_FATAL_HELPER=1")
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("expected exit code 1, got %v\n%s", err, out)
+	}
+	if !strings.Contains(string(out), "worker stopped cleanly") {
+		t.Fatalf("worker was not stopped:\n%s", out)
+	}
+}
@@ -0,0 +1,56 @@
+package lifecycle
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+)
+
+// Option is a function that changes a Manager during initialization
+type Option func(*Manager) error
+
+// SetLogger sets the logger for start and stop events
+func SetLogger(l kitlog.Logger) Option {
+	return func(m *Manager) error {
+		if l == nil {
+			return errors.New("lifecycle: nil logger passed")
+		}
+		m.log = l
+		return nil
+	}
+}
+
+// SetStartTimeout sets how long each hook has to start
+func SetStartTimeout(d time.Duration) Option {
+	return func(m *Manager) error {
+		if d <= 0 {
+			return errors.New("lifecycle: start timeout needs to be positive")
+		}
+		m.startTimeout = d
+		return nil
+	}
+}
+
+// SetStopTimeout sets how long all hooks have to stop, together
+func SetStopTimeout(d time.Duration) Option {
+	return func(m *Manager) error {
+		if d <= 0 {
+			return errors.New("lifecycle: stop timeout needs to be positive")
+		}
+		m.stopTimeout = d
+		return nil
+	}
+}
+
+// SetSignals sets the signals Run shuts down on
+func SetSignals(sigs ...os.Signal) Option {
+	return func(m *Manager) error {
+		if len(sigs) == 0 {
+			return errors.New("lifecycle: no signals passed")
+		}
+		m.signals = sigs
+		return nil
+	}
+}
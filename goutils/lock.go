@@ -0,0 +1,51 @@
+package goutils
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// ErrLocked is returned by TryLockFile if another process holds the lock
+var ErrLocked = errors.New("file is locked")
+
+// FileLock is an exclusive advisory lock on a file, see LockFile
+type FileLock struct {
+	f *os.File
+}
+
+// LockFile opens (or creates) path and blocks until it holds an exclusive lock on it.
+// The lock is advisory and only excludes other users of LockFile and flock(2).
+func LockFile(path string) (*FileLock, error) {
+	return lockFile(path, true)
+}
+
+// TryLockFile is like LockFile but returns ErrLocked instead of waiting
+func TryLockFile(path string) (*FileLock, error) {
+	return lockFile(path, false)
+}
+
+func lockFile(path string, block bool) (*FileLock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "LockFile: failed to open")
+	}
+
+	if err := flock(f, block); err != nil {
+		f.Close()
+		if err == ErrLocked {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, "LockFile: failed to lock")
+	}
+	return &FileLock{f: f}, nil
+}
+
+// Unlock releases the lock and closes the file
+func (l *FileLock) Unlock() error {
+	if err := funlock(l.f); err != nil {
+		l.f.Close()
+		return errors.Wrap(err, "FileLock: failed to unlock")
+	}
+	return errors.Wrap(l.f.Close(), "FileLock: failed to close")
+}
@@ -0,0 +1,18 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package goutils
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+func flock(f *os.File, block bool) error {
+	return errors.New("file locking is not supported on this platform")
+}
+
+func funlock(f *os.File) error {
+	return errors.New("file locking is not supported on this platform")
+}
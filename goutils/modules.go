@@ -0,0 +1,254 @@
+package goutils
+
+import (
+	"bufio"
+	"go/build"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// goMod holds the parts of a go.mod file that are needed to resolve import paths
+type goMod struct {
+	Dir     string // the directory of the go.mod file
+	Module  string
+	Require map[string]string // module path => version
+	Replace []modReplace
+}
+
+type modReplace struct {
+	Old, OldVersion string
+	New, NewVersion string
+}
+
+// findGoMod walks up from dir and returns the path of the first go.mod it finds or an empty string
+func findGoMod(dir string) string {
+	for {
+		p := filepath.Join(dir, "go.mod")
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			return p
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// parseGoMod reads the module, require and replace directives of a go.mod file
+func parseGoMod(path string) (*goMod, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "parseGoMod: failed to open")
+	}
+	defer f.Close()
+
+	mf := &goMod{
+		Dir:     filepath.Dir(path),
+		Require: make(map[string]string),
+	}
+
+	var (
+		block  string // the verb of the current ( ... ) block
+		lineNo int
+		s      = bufio.NewScanner(f)
+	)
+	for s.Scan() {
+		lineNo++
+		line := s.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		verb := block
+		if block == "" {
+			verb, fields = fields[0], fields[1:]
+			if len(fields) == 1 && fields[0] == "(" {
+				block = verb
+				continue
+			}
+		} else if fields[0] == ")" {
+			block = ""
+			continue
+		}
+
+		for i, fld := range fields {
+			if uq, err := strconv.Unquote(fld); err == nil {
+				fields[i] = uq
+			}
+		}
+
+		switch verb {
+		case "module":
+			if len(fields) != 1 {
+				return nil, errors.Errorf("parseGoMod: %s:%d: malformed module directive", path, lineNo)
+			}
+			mf.Module = fields[0]
+
+		case "require":
+			if len(fields) != 2 {
+				return nil, errors.Errorf("parseGoMod: %s:%d: malformed require directive", path, lineNo)
+			}
+			mf.Require[fields[0]] = fields[1]
+
+		case "replace":
+			var r modReplace
+			switch {
+			case len(fields) == 3 && fields[1] == "=>":
+				r.Old, r.New = fields[0], fields[2]
+			case len(fields) == 4 && fields[1] == "=>":
+				r.Old, r.New, r.NewVersion = fields[0], fields[2], fields[3]
+			case len(fields) == 4 && fields[2] == "=>":
+				r.Old, r.OldVersion, r.New = fields[0], fields[1], fields[3]
+			case len(fields) == 5 && fields[2] == "=>":
+				r.Old, r.OldVersion, r.New, r.NewVersion = fields[0], fields[1], fields[3], fields[4]
+			default:
+				return nil, errors.Errorf("parseGoMod: %s:%d: malformed replace directive", path, lineNo)
+			}
+			mf.Replace = append(mf.Replace, r)
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "parseGoMod: failed to read")
+	}
+
+	if mf.Module == "" {
+		return nil, errors.Errorf("parseGoMod: %s has no module directive", path)
+	}
+	return mf, nil
+}
+
+// subPath returns the part of the import path inside the module mod
+func subPath(importPath, mod string) (string, bool) {
+	if importPath == mod {
+		return "", true
+	}
+	if strings.HasPrefix(importPath, mod+"/") {
+		return importPath[len(mod)+1:], true
+	}
+	return "", false
+}
+
+// locate resolves an import path to the directory of the package,
+// using the main module, its vendor directory, replace directives and the module cache.
+func (mf *goMod) locate(importPath string) (string, error) {
+	if sub, ok := subPath(importPath, mf.Module); ok {
+		return existingDir(filepath.Join(mf.Dir, filepath.FromSlash(sub)))
+	}
+
+	if useVendor(mf.Dir) {
+		if dir, err := existingDir(filepath.Join(mf.Dir, "vendor", filepath.FromSlash(importPath))); err == nil {
+			return dir, nil
+		}
+	}
+
+	// the longest matching module path wins
+	var mod string
+	consider := func(m string) {
+		if _, ok := subPath(importPath, m); ok && len(m) > len(mod) {
+			mod = m
+		}
+	}
+	for m := range mf.Require {
+		consider(m)
+	}
+	for _, r := range mf.Replace {
+		consider(r.Old)
+	}
+	if mod == "" {
+		return "", errors.Errorf("no required module provides %s", importPath)
+	}
+
+	// a replacement for the required version takes precedence over one for all versions
+	var repl *modReplace
+	for i, r := range mf.Replace {
+		if r.Old != mod {
+			continue
+		}
+		if (r.OldVersion == "" && repl == nil) || (r.OldVersion != "" && r.OldVersion == mf.Require[mod]) {
+			repl = &mf.Replace[i]
+		}
+	}
+	sub, _ := subPath(importPath, mod)
+
+	if repl != nil {
+		if repl.NewVersion == "" { // local directory
+			dir := filepath.FromSlash(repl.New)
+			if !filepath.IsAbs(dir) {
+				dir = filepath.Join(mf.Dir, dir)
+			}
+			return existingDir(filepath.Join(dir, filepath.FromSlash(sub)))
+		}
+		return moduleCacheDir(repl.New, repl.NewVersion, sub)
+	}
+
+	return moduleCacheDir(mod, mf.Require[mod], sub)
+}
+
+// useVendor mirrors the go command: -mod=vendor forces it, otherwise it's used if vendor/modules.txt exists and -mod isn't set
+func useVendor(root string) bool {
+	for _, f := range strings.Fields(os.Getenv("GOFLAGS")) {
+		if strings.HasPrefix(f, "-mod=") {
+			return f == "-mod=vendor"
+		}
+	}
+	_, err := os.Stat(filepath.Join(root, "vendor", "modules.txt"))
+	return err == nil
+}
+
+// moduleCacheDir returns the directory of sub in module mod@version inside the local module cache
+func moduleCacheDir(mod, version, sub string) (string, error) {
+	cache := os.Getenv("GOMODCACHE")
+	if cache == "" {
+		gopath := filepath.SplitList(build.Default.GOPATH)
+		if len(gopath) == 0 {
+			return "", errors.New("could not determine module cache location")
+		}
+		cache = filepath.Join(gopath[0], "pkg", "mod")
+	}
+
+	dir := filepath.Join(cache, escapeModPath(mod)+"@"+escapeModPath(version), filepath.FromSlash(sub))
+	return existingDir(dir)
+}
+
+// escapeModPath encodes upper case letters like the module cache does (Azure => !azure)
+func escapeModPath(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if unicode.IsUpper(r) {
+			b.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func existingDir(dir string) (string, error) {
+	fi, err := os.Stat(dir)
+	if err != nil {
+		return "", err
+	}
+	if !fi.IsDir() {
+		return "", errors.Errorf("%s is not a directory", dir)
+	}
+	return dir, nil
+}
+
+// isStdlib reports whether the first element of the import path lacks a dot, like the go command does
+func isStdlib(importPath string) bool {
+	elem := importPath
+	if i := strings.Index(elem, "/"); i >= 0 {
+		elem = elem[:i]
+	}
+	return !strings.Contains(elem, ".")
+}
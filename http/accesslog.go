@@ -0,0 +1,173 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+
+	"go.mindeco.de/logging"
+)
+
+type accessLog struct {
+	fallback kitlog.Logger
+
+	sample float64
+	rand   func() float64
+
+	skip map[string]bool
+
+	combinedMu sync.Mutex
+	combined   io.Writer
+}
+
+// AccessLogOption is a function that changes the AccessLog middleware during initialization
+type AccessLogOption func(*accessLog) error
+
+// SetAccessLogger sets the logger for requests that don't have one from logging.InjectHandler in their context
+func SetAccessLogger(l kitlog.Logger) AccessLogOption {
+	return func(al *accessLog) error {
+		if l == nil {
+			return errors.New("logger can't be nil")
+		}
+		al.fallback = l
+		return nil
+	}
+}
+
+// SetSampleRate only logs the given fraction of the requests. Responses with a status of 400 or above are always logged.
+func SetSampleRate(rate float64) AccessLogOption {
+	return func(al *accessLog) error {
+		if rate <= 0 || rate > 1 {
+			return errors.New("sample rate needs to be in (0, 1]")
+		}
+		al.sample = rate
+		return nil
+	}
+}
+
+// SkipPaths doesn't log requests to the given paths, like health checks
+func SkipPaths(paths ...string) AccessLogOption {
+	return func(al *accessLog) error {
+		for _, p := range paths {
+			al.skip[p] = true
+		}
+		return nil
+	}
+}
+
+// SetCombinedOutput additionally writes every logged request in the Apache combined log format to w
+func SetCombinedOutput(w io.Writer) AccessLogOption {
+	return func(al *accessLog) error {
+		if w == nil {
+			return errors.New("combined log writer can't be nil")
+		}
+		al.combined = w
+		return nil
+	}
+}
+
+// AccessLog logs method, status, body size and latency of every request.
+// The logger is taken from the request context (see logging.InjectHandler), so it should come after it in a Chain.
+func AccessLog(opts ...AccessLogOption) (MiddlewareFunc, error) {
+	al := &accessLog{
+		sample: 1,
+		rand:   rand.Float64,
+		skip:   make(map[string]bool),
+	}
+	for _, o := range opts {
+		if err := o(al); err != nil {
+			return nil, err
+		}
+	}
+	if al.fallback == nil {
+		al.fallback = kitlog.NewNopLogger()
+	}
+	return al.middleware, nil
+}
+
+func (al *accessLog) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if al.skip[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := newResponseRecorder(w)
+		next.ServeHTTP(rec, r)
+		took := time.Since(start)
+
+		if rec.status < 400 && al.sample < 1 && al.rand() >= al.sample {
+			return
+		}
+
+		kv := []interface{}{
+			"event", "request",
+			"method", r.Method,
+			"status", rec.status,
+			"bytes", rec.bytes,
+			"took", took,
+			"remote", r.RemoteAddr,
+		}
+		if id := rec.Header().Get(HeaderRequestID); id != "" {
+			kv = append(kv, "requestID", id)
+		}
+		l := logging.FromContext(r.Context())
+		if l == nil {
+			l = al.fallback
+			kv = append(kv, "urlPath", r.URL.Path)
+		}
+		l.Log(kv...)
+
+		if al.combined != nil {
+			al.writeCombined(r, rec, start)
+		}
+	})
+}
+
+// writeCombined writes: host ident user [time] "request line" status bytes "referer" "user agent"
+func (al *accessLog) writeCombined(r *http.Request, rec *responseRecorder, start time.Time) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	user := "-"
+	if u, _, ok := r.BasicAuth(); ok && u != "" {
+		user = u
+	} else if r.URL.User != nil && r.URL.User.Username() != "" {
+		user = r.URL.User.Username()
+	}
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+	size := "-"
+	if rec.bytes > 0 {
+		size = strconv.FormatInt(rec.bytes, 10)
+	}
+
+	line := fmt.Sprintf("%s - %s [%s] %s %d %s %s %s\n",
+		host, user, start.Format("02/Jan/2006:15:04:05 -0700"),
+		strconv.Quote(r.Method+" "+uri+" "+r.Proto),
+		rec.status, size,
+		strconv.Quote(orDash(r.Referer())), strconv.Quote(orDash(r.UserAgent())))
+
+	al.combinedMu.Lock()
+	io.WriteString(al.combined, line)
+	al.combinedMu.Unlock()
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+package http
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+
+	"go.mindeco.de/logging"
+)
+
+func TestAccessLog(t *testing.T) {
+	var logBuf, combined bytes.Buffer
+	mw, err := AccessLog(
+		SetAccessLogger(kitlog.NewLogfmtLogger(&logBuf)),
+		SkipPaths("/healthz"),
+		SetCombinedOutput(&combined),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewChain(mw, RequestID()).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("hello"))
+	})
+
+	req := httptest.NewRequest("GET", "/greet?x=1", nil)
+	req.Header.Set("User-Agent", "tester/1.0")
+	req.SetBasicAuth("alice", "pw")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/missing", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/healthz", nil))
+
+	lines := strings.Split(strings.TrimSpace(logBuf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got %q", lines)
+	}
+	ok := regexp.MustCompile(`^event=request method=GET status=200 bytes=5 took=\S+ remote=192\.0\.2\.1:1234 requestID=[0-9a-f]{32} urlPath=/greet$`)
+	if !ok.MatchString(lines[0]) {
+		t.Errorf("wrong line: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "status=404") {
+		t.Errorf("wrong line: %s", lines[1])
+	}
+
+	apache := regexp.MustCompile(`^192\.0\.2\.1 - alice \[\d\d/\w{3}/\d{4}:\d\d:\d\d:\d\d [+-]\d{4}\] "GET /greet\?x=1 HTTP/1\.1" 200 5 "-" "tester/1\.0"$`)
+	cl := strings.Split(strings.TrimSpace(combined.String()), "\n")
+	if len(cl) != 2 || !apache.MatchString(cl[0]) {
+		t.Errorf("wrong combined output: %q", cl)
+	}
+}
+
+func TestAccessLog_contextLogger(t *testing.T) {
+	var ctxBuf, fallbackBuf bytes.Buffer
+	mw, err := AccessLog(SetAccessLogger(kitlog.NewLogfmtLogger(&fallbackBuf)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewChain(logging.InjectHandler(kitlog.NewLogfmtLogger(&ctxBuf)), mw).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		w.WriteHeader(http.StatusOK) // superfluous, ignored
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("PUT", "/thing", nil))
+
+	if fallbackBuf.Len() != 0 {
+		t.Errorf("fallback used: %s", fallbackBuf.String())
+	}
+	if l := ctxBuf.String(); !strings.HasPrefix(l, "urlPath=/thing event=request method=PUT status=201 bytes=0") {
+		t.Errorf("wrong line: %s", l)
+	}
+}
+
+func TestAccessLog_sampling(t *testing.T) {
+	var logBuf bytes.Buffer
+	al := &accessLog{
+		fallback: kitlog.NewLogfmtLogger(&logBuf),
+		sample:   0.5,
+		skip:     make(map[string]bool),
+	}
+	draws := []float64{0.9, 0.1, 0.9}
+	al.rand = func() float64 {
+		d := draws[0]
+		draws = draws[1:]
+		return d
+	}
+	h := al.middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/err" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+
+	for _, p := range []string{"/a", "/b", "/err"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", p, nil))
+	}
+	lines := strings.Split(strings.TrimSpace(logBuf.String()), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], "urlPath=/b") || !strings.Contains(lines[1], "status=500") {
+		t.Errorf("wrong sampling: %q", lines)
+	}
+
+	if _, err := AccessLog(SetSampleRate(0)); err == nil {
+		t.Error("zero rate accepted")
+	}
+}
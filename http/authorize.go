@@ -0,0 +1,304 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Headers of signed requests, see SignRequest
+const (
+	HeaderKeyID     = "X-Auth-Key-Id"
+	HeaderTimestamp = "X-Auth-Timestamp"
+	HeaderNonce     = "X-Auth-Nonce"
+	HeaderSignature = "X-Auth-Signature"
+)
+
+// MaxSignedBodySize is the largest body the Authorizer reads to verify a signature
+var MaxSignedBodySize int64 = 10 << 20
+
+// Key is a shared secret with an ID, so that keys can be rotated by having an old and a new one active.
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// Authorizer checks requests against a set of keys.
+// Depending on the options it accepts the secret in a header, as a bearer token or as the key for HMAC signed requests.
+// Secrets are compared in constant time and the ID of the matching key is put in the request context, see KeyIDFromContext.
+type Authorizer struct {
+	keys []Key
+
+	header string
+	bearer bool
+
+	signed bool
+	skew   time.Duration
+	nonces *nonceCache
+
+	now func() time.Time
+}
+
+// AuthOption is a function that changes an Authorizer during initialization
+type AuthOption func(*Authorizer) error
+
+// AcceptHeader accepts requests that carry one of the secrets in the named header
+func AcceptHeader(name string) AuthOption {
+	return func(a *Authorizer) error {
+		if name == "" {
+			return errors.New("header name can't be empty")
+		}
+		a.header = name
+		return nil
+	}
+}
+
+// AcceptBearer accepts requests with one of the secrets as a bearer token (Authorization: Bearer <secret>)
+func AcceptBearer() AuthOption {
+	return func(a *Authorizer) error {
+		a.bearer = true
+		return nil
+	}
+}
+
+// AcceptSignatures accepts requests signed with SignRequest whose timestamp is at most maxSkew away from now.
+// Nonces are remembered for twice that window and a request that reuses one is rejected.
+func AcceptSignatures(maxSkew time.Duration) AuthOption {
+	return func(a *Authorizer) error {
+		if maxSkew <= 0 {
+			return errors.New("signature skew needs to be positive")
+		}
+		a.signed = true
+		a.skew = maxSkew
+		return nil
+	}
+}
+
+// NewAuthorizer returns an Authorizer for keys. At least one of the Accept options is required.
+func NewAuthorizer(keys []Key, opts ...AuthOption) (*Authorizer, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("cryptix/http: authorizer needs at least one key")
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if k.ID == "" || len(k.Secret) == 0 {
+			return nil, errors.New("cryptix/http: keys need an id and a secret")
+		}
+		if seen[k.ID] {
+			return nil, errors.New("cryptix/http: duplicate key id " + k.ID)
+		}
+		seen[k.ID] = true
+	}
+
+	a := &Authorizer{keys: keys, now: time.Now}
+	for _, o := range opts {
+		if err := o(a); err != nil {
+			return nil, err
+		}
+	}
+	if a.header == "" && !a.bearer && !a.signed {
+		return nil, errors.New("cryptix/http: authorizer accepts nothing")
+	}
+	if a.signed {
+		a.nonces = newNonceCache(2 * a.skew)
+	}
+	return a, nil
+}
+
+// Middleware rejects unauthorized requests with http.StatusUnauthorized
+func (a *Authorizer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, status := a.authorize(r)
+		if status != http.StatusOK {
+			if a.bearer {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+			}
+			http.Error(w, http.StatusText(status), status)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), keyIDCtxKey{}, id)))
+	})
+}
+
+func (a *Authorizer) authorize(r *http.Request) (string, int) {
+	if a.header != "" {
+		if id, ok := a.match(r.Header.Get(a.header)); ok {
+			return id, http.StatusOK
+		}
+	}
+	if a.bearer {
+		const prefix = "Bearer "
+		if hv := r.Header.Get("Authorization"); len(hv) > len(prefix) && strings.EqualFold(hv[:len(prefix)], prefix) {
+			if id, ok := a.match(hv[len(prefix):]); ok {
+				return id, http.StatusOK
+			}
+		}
+	}
+	if a.signed && r.Header.Get(HeaderSignature) != "" {
+		return a.verify(r)
+	}
+	return "", http.StatusUnauthorized
+}
+
+// match compares v against all secrets, without stopping at the first match
+func (a *Authorizer) match(v string) (string, bool) {
+	if v == "" {
+		return "", false
+	}
+	var id string
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare([]byte(v), k.Secret) == 1 {
+			id = k.ID
+		}
+	}
+	return id, id != ""
+}
+
+func (a *Authorizer) verify(r *http.Request) (string, int) {
+	key, ok := a.key(r.Header.Get(HeaderKeyID))
+	if !ok {
+		return "", http.StatusUnauthorized
+	}
+
+	ts := r.Header.Get(HeaderTimestamp)
+	secs, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return "", http.StatusUnauthorized
+	}
+	if d := a.now().Sub(time.Unix(secs, 0)); d > a.skew || d < -a.skew {
+		return "", http.StatusUnauthorized
+	}
+
+	nonce := r.Header.Get(HeaderNonce)
+	if nonce == "" || len(nonce) > 128 {
+		return "", http.StatusUnauthorized
+	}
+
+	got, err := hex.DecodeString(r.Header.Get(HeaderSignature))
+	if err != nil {
+		return "", http.StatusUnauthorized
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = ioutil.ReadAll(io.LimitReader(r.Body, MaxSignedBodySize+1))
+		r.Body.Close()
+		if err != nil {
+			return "", http.StatusBadRequest
+		}
+		if int64(len(body)) > MaxSignedBodySize {
+			return "", http.StatusRequestEntityTooLarge
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	want := signature(key.Secret, r.Method, r.URL.RequestURI(), ts, nonce, body)
+	if !hmac.Equal(got, want) {
+		return "", http.StatusUnauthorized
+	}
+
+	// only remember nonces of valid signatures, otherwise anyone could burn them
+	if !a.nonces.add(key.ID+"\n"+nonce, a.now()) {
+		return "", http.StatusUnauthorized
+	}
+	return key.ID, http.StatusOK
+}
+
+func (a *Authorizer) key(id string) (Key, bool) {
+	for _, k := range a.keys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// SignRequest sets the headers that an Authorizer with AcceptSignatures verifies.
+// The signature is a hex encoded HMAC-SHA256 over the method, request URI, timestamp, nonce and the SHA256 of the body.
+// The body is read and replaced with an equivalent reader.
+// Since nonces can only be used once, a request that is sent again needs to be signed again.
+func SignRequest(req *http.Request, key Key, nonce string, now time.Time) error {
+	if nonce == "" {
+		return errors.New("cryptix/http: nonce can't be empty")
+	}
+	var body []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return err
+		}
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(body)), nil
+		}
+	}
+
+	ts := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set(HeaderKeyID, key.ID)
+	req.Header.Set(HeaderTimestamp, ts)
+	req.Header.Set(HeaderNonce, nonce)
+	req.Header.Set(HeaderSignature, hex.EncodeToString(signature(key.Secret, req.Method, req.URL.RequestURI(), ts, nonce, body)))
+	return nil
+}
+
+func signature(secret []byte, method, uri, ts, nonce string, body []byte) []byte {
+	digest := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	io.WriteString(mac, method+"\n"+uri+"\n"+ts+"\n"+nonce+"\n")
+	io.WriteString(mac, hex.EncodeToString(digest[:]))
+	return mac.Sum(nil)
+}
+
+type keyIDCtxKey struct{}
+
+// KeyIDFromContext returns the id of the key that authorized the request
+func KeyIDFromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(keyIDCtxKey{}).(string)
+	return id, ok
+}
+
+// nonceCache remembers nonces until they are too old to pass the timestamp check anyway
+type nonceCache struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	seen   map[string]time.Time
+	lastGC time.Time
+}
+
+func newNonceCache(ttl time.Duration) *nonceCache {
+	return &nonceCache{ttl: ttl, seen: make(map[string]time.Time)}
+}
+
+// add returns false if the nonce was already seen
+func (c *nonceCache) add(nonce string, now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now.Sub(c.lastGC) > c.ttl {
+		for n, t := range c.seen {
+			if now.Sub(t) > c.ttl {
+				delete(c.seen, n)
+			}
+		}
+		c.lastGC = now
+	}
+
+	if t, has := c.seen[nonce]; has && now.Sub(t) <= c.ttl {
+		return false
+	}
+	c.seen[nonce] = now
+	return true
+}
@@ -0,0 +1,163 @@
+package http
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testKeys = []Key{
+	{ID: "old", Secret: []byte("hunter2")},
+	{ID: "new", Secret: []byte("correct horse")},
+}
+
+func newTestAuthorizer(t *testing.T, now time.Time, opts ...AuthOption) http.Handler {
+	a, err := NewAuthorizer(testKeys, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.now = func() time.Time { return now }
+	return a.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := KeyIDFromContext(r.Context())
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte(id + ":" + string(body)))
+	}))
+}
+
+func serve(h http.Handler, req *http.Request) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAuthorize_constantTime(t *testing.T) {
+	h := Authorize("X-Token", "s3cr3t")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for v, want := range map[string]int{"s3cr3t": 200, "s3cr3": 401, "": 401, "s3cr3t!": 401} {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Token", v)
+		if rec := serve(h, req); rec.Code != want {
+			t.Errorf("%q: got %d", v, rec.Code)
+		}
+	}
+}
+
+func TestAuthorizer_headerAndBearer(t *testing.T) {
+	h := newTestAuthorizer(t, time.Now(), AcceptHeader("X-Api-Key"), AcceptBearer())
+
+	tcases := []struct {
+		header, value string
+		status        int
+		body          string
+	}{
+		{"X-Api-Key", "hunter2", 200, "old:"},
+		{"X-Api-Key", "correct horse", 200, "new:"},
+		{"Authorization", "Bearer correct horse", 200, "new:"},
+		{"Authorization", "bearer hunter2", 200, "old:"},
+		{"Authorization", "Basic hunter2", 401, ""},
+		{"X-Api-Key", "hunter", 401, ""},
+		{"X-Other", "hunter2", 401, ""},
+	}
+	for i, tc := range tcases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set(tc.header, tc.value)
+		rec := serve(h, req)
+		if rec.Code != tc.status {
+			t.Errorf("%d: got %d", i, rec.Code)
+			continue
+		}
+		if tc.status == 200 && rec.Body.String() != tc.body {
+			t.Errorf("%d: got %q", i, rec.Body.String())
+		}
+		if tc.status == 401 && rec.Header().Get("WWW-Authenticate") != "Bearer" {
+			t.Errorf("%d: missing challenge", i)
+		}
+	}
+}
+
+func TestAuthorizer_signed(t *testing.T) {
+	now := time.Unix(1500000000, 0)
+	h := newTestAuthorizer(t, now, AcceptSignatures(time.Minute))
+
+	newSigned := func(nonce string, ts time.Time) *http.Request {
+		req := httptest.NewRequest("POST", "/things?x=1", strings.NewReader(`{"a":1}`))
+		if err := SignRequest(req, testKeys[1], nonce, ts); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	rec := serve(h, newSigned("n1", now.Add(-30*time.Second)))
+	if rec.Code != 200 || rec.Body.String() != `new:{"a":1}` {
+		t.Fatalf("got %d %q", rec.Code, rec.Body.String())
+	}
+
+	// replay
+	if rec := serve(h, newSigned("n1", now.Add(-30*time.Second))); rec.Code != 401 {
+		t.Errorf("replay accepted: %d", rec.Code)
+	}
+
+	// too old
+	if rec := serve(h, newSigned("n2", now.Add(-2*time.Minute))); rec.Code != 401 {
+		t.Errorf("stale accepted: %d", rec.Code)
+	}
+
+	// tampered body
+	req := newSigned("n3", now)
+	req.Body = ioutil.NopCloser(strings.NewReader(`{"a":2}`))
+	if rec := serve(h, req); rec.Code != 401 {
+		t.Errorf("tampered body accepted: %d", rec.Code)
+	}
+
+	// tampered path
+	req = newSigned("n4", now)
+	req.URL.RawQuery = "x=2"
+	if rec := serve(h, req); rec.Code != 401 {
+		t.Errorf("tampered query accepted: %d", rec.Code)
+	}
+
+	// unknown key
+	req = httptest.NewRequest("GET", "/", nil)
+	SignRequest(req, Key{ID: "other", Secret: []byte("correct horse")}, "n5", now)
+	if rec := serve(h, req); rec.Code != 401 {
+		t.Errorf("unknown key accepted: %d", rec.Code)
+	}
+
+	// the rejected nonces are still usable
+	if rec := serve(h, newSigned("n3", now)); rec.Code != 200 {
+		t.Errorf("valid request rejected: %d", rec.Code)
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	c := newNonceCache(time.Minute)
+	start := time.Unix(0, 0)
+	if !c.add("a", start) || c.add("a", start.Add(time.Second)) {
+		t.Fatal("replay not detected")
+	}
+	if !c.add("a", start.Add(2*time.Minute)) {
+		t.Error("expired nonce still blocked")
+	}
+	c.add("b", start.Add(2*time.Minute))
+	c.add("c", start.Add(4*time.Minute))
+	if len(c.seen) != 1 {
+		t.Errorf("old nonces not collected: %v", c.seen)
+	}
+}
+
+func TestNewAuthorizer_invalid(t *testing.T) {
+	if _, err := NewAuthorizer(nil, AcceptBearer()); err == nil {
+		t.Error("no keys accepted")
+	}
+	if _, err := NewAuthorizer(testKeys); err == nil {
+		t.Error("no scheme accepted")
+	}
+	if _, err := NewAuthorizer([]Key{{ID: "a", Secret: []byte("x")}, {ID: "a", Secret: []byte("y")}}, AcceptBearer()); err == nil {
+		t.Error("duplicate ids accepted")
+	}
+	if _, err := NewAuthorizer(testKeys, AcceptSignatures(0)); err == nil {
+		t.Error("zero skew accepted")
+	}
+}
@@ -0,0 +1,49 @@
+package http
+
+import "net/http"
+
+// Chain is an immutable list of middlewares.
+// The first middleware is the outermost one, it sees the request first and the response last.
+//
+//	chain := NewChain(logging.InjectHandler(log), logging.RecoveryHandler(), RequestID())
+//	http.Handle("/api/", chain.Append(Authorize("X-Token", token)).Then(api))
+type Chain struct {
+	mws []MiddlewareFunc
+}
+
+// NewChain returns a Chain of mws
+func NewChain(mws ...MiddlewareFunc) Chain {
+	return Chain{}.Append(mws...)
+}
+
+// Append returns a new Chain with mws added after the existing ones. c is not changed.
+func (c Chain) Append(mws ...MiddlewareFunc) Chain {
+	n := make([]MiddlewareFunc, 0, len(c.mws)+len(mws))
+	n = append(n, c.mws...)
+	n = append(n, mws...)
+	return Chain{mws: n}
+}
+
+// Extend returns a new Chain with the middlewares of other added after the ones of c
+func (c Chain) Extend(other Chain) Chain {
+	return c.Append(other.mws...)
+}
+
+// Then wraps h in all middlewares of the chain. A nil h means http.DefaultServeMux.
+func (c Chain) Then(h http.Handler) http.Handler {
+	if h == nil {
+		h = http.DefaultServeMux
+	}
+	for i := len(c.mws) - 1; i >= 0; i-- {
+		h = c.mws[i](h)
+	}
+	return h
+}
+
+// ThenFunc is Then for a function. A nil fn means http.DefaultServeMux.
+func (c Chain) ThenFunc(fn http.HandlerFunc) http.Handler {
+	if fn == nil {
+		return c.Then(nil)
+	}
+	return c.Then(fn)
+}
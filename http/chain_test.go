@@ -0,0 +1,62 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+
+	"go.mindeco.de/logging"
+)
+
+func tagger(tag string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(tag))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func TestChain(t *testing.T) {
+	base := NewChain(tagger("a"), tagger("b"))
+	one := base.Append(tagger("c"))
+	two := base.Append(tagger("d"))
+	both := one.Extend(NewChain(tagger("e")))
+
+	final := func(w http.ResponseWriter, r *http.Request) { w.Write([]byte("!")) }
+
+	tcases := []struct {
+		c    Chain
+		want string
+	}{
+		{Chain{}, "!"},
+		{base, "ab!"},
+		{one, "abc!"},
+		{two, "abd!"},
+		{both, "abce!"},
+	}
+	for i, tc := range tcases {
+		rec := httptest.NewRecorder()
+		tc.c.ThenFunc(final).ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+		if got := rec.Body.String(); got != tc.want {
+			t.Errorf("%d: got %q", i, got)
+		}
+	}
+
+	if base.Then(nil) == nil {
+		t.Error("nil handler not defaulted")
+	}
+}
+
+func TestChain_loggingHandlers(t *testing.T) {
+	var seen bool
+	h := NewChain(logging.InjectHandler(kitlog.NewNopLogger()), RequestID()).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context()) != "" && logging.FromContext(r.Context()) != nil
+	})
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if !seen {
+		t.Error("request id or logger missing")
+	}
+}
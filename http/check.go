@@ -1,40 +1,249 @@
 package http
 
 import (
+	"bytes"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"io/ioutil"
+	"math"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
+// Sentinel errors an *ErrorResponse matches with errors.Is, depending on its status code
+var (
+	ErrNotFound     = errors.New("cryptix/http: not found")
+	ErrUnauthorized = errors.New("cryptix/http: unauthorized")
+	ErrRateLimited  = errors.New("cryptix/http: rate limited")
+	ErrServer       = errors.New("cryptix/http: server error")
+)
+
+// MaxErrorBodySize is the number of bytes CheckResponse reads from an error body at most.
+// Anything beyond it is dropped and ErrorResponse.Truncated is set.
+var MaxErrorBodySize int64 = 1 << 20
+
 // An ErrorResponse reports errors caused by an API request.
+//
+// JSON bodies are decoded into the typed fields: RFC 7807 problem details (application/problem+json)
+// fill Type, Title, Detail and Instance, common shapes like {"error": "..."}, {"message": "...", "code": "..."}
+// or {"error": {"message": "..."}} fill Code and Message. Body always holds the raw body.
 type ErrorResponse struct {
 	Response *http.Response `json:",omitempty"`
 	Body     []byte
+
+	// Truncated is set if the body was larger than the read limit and Body only holds its start
+	Truncated bool `json:",omitempty"`
+
+	// RFC 7807 problem details
+	Type     string `json:",omitempty"`
+	Title    string `json:",omitempty"`
+	Detail   string `json:",omitempty"`
+	Instance string `json:",omitempty"`
+
+	Code    string                 `json:",omitempty"`
+	Message string                 `json:",omitempty"`
+	Details map[string]interface{} `json:",omitempty"` // all fields of a JSON object body
+
+	// RetryAfter is parsed from the Retry-After header, zero if there is none
+	RetryAfter time.Duration `json:",omitempty"`
 }
 
 func (r *ErrorResponse) Error() string {
+	msg := r.Message
+	if msg == "" {
+		msg = r.Detail
+	}
+	if msg == "" {
+		msg = r.Title
+	}
+	if msg == "" {
+		msg = string(r.Body)
+	}
 	return fmt.Sprintf("%v %v: %d\n%v",
 		r.Response.Request.Method, r.Response.Request.URL,
-		r.Response.StatusCode, string(r.Body))
+		r.Response.StatusCode, msg)
 }
 
+// Is matches the sentinel errors of this package by status code
+func (r *ErrorResponse) Is(target error) bool {
+	if r.Response == nil {
+		return false
+	}
+	c := r.Response.StatusCode
+	switch target {
+	case ErrNotFound:
+		return c == http.StatusNotFound
+	case ErrUnauthorized:
+		return c == http.StatusUnauthorized
+	case ErrRateLimited:
+		return c == http.StatusTooManyRequests
+	case ErrServer:
+		return c >= 500 && c <= 599
+	}
+	return false
+}
+
+// IsNotFound reports whether err is an *ErrorResponse with status 404
+func IsNotFound(err error) bool { return stderrors.Is(err, ErrNotFound) }
+
+// IsUnauthorized reports whether err is an *ErrorResponse with status 401
+func IsUnauthorized(err error) bool { return stderrors.Is(err, ErrUnauthorized) }
+
+// IsRateLimited reports whether err is an *ErrorResponse with status 429
+func IsRateLimited(err error) bool { return stderrors.Is(err, ErrRateLimited) }
+
+// IsServerError reports whether err is an *ErrorResponse with a 5xx status
+func IsServerError(err error) bool { return stderrors.Is(err, ErrServer) }
+
 // CheckResponse checks the API response for errors, and returns them if
 // present. A response is considered an error if it has a status code outside
 // the 200 range. API error responses are expected to have either no response
 // body, or a JSON response body that maps to ErrorResponse. Any other
-// response body will be silently ignored.
+// response body is only kept in ErrorResponse.Body.
+//
+// At most MaxErrorBodySize bytes of the body are read and the body is closed afterwards.
 func CheckResponse(r *http.Response) error {
+	return CheckResponseLimit(r, MaxErrorBodySize)
+}
+
+// CheckResponseLimit is like CheckResponse but reads at most limit bytes of the body.
+func CheckResponseLimit(r *http.Response, limit int64) error {
+	er, err := checkResponse(r, limit)
+	if err != nil || er == nil {
+		return err
+	}
+	return er
+}
+
+// CheckResponseReplayable is like CheckResponse but replaces r.Body with a reader over the bytes
+// that were read, so that logging middleware or the caller can read the error body again.
+// If the body was truncated the replacement only holds the first MaxErrorBodySize bytes.
+func CheckResponseReplayable(r *http.Response) error {
+	er, err := checkResponse(r, MaxErrorBodySize)
+	if err != nil || er == nil {
+		return err
+	}
+	r.Body = ioutil.NopCloser(bytes.NewReader(er.Body))
+	return er
+}
+
+func checkResponse(r *http.Response, limit int64) (*ErrorResponse, error) {
 	if c := r.StatusCode; 200 <= c && c <= 299 {
-		return nil
+		return nil, nil
 	}
 	errorResponse := &ErrorResponse{Response: r}
-	var err error
-	errorResponse.Body, err = ioutil.ReadAll(r.Body)
-	if err != nil {
-		return errors.Wrapf(err, "cryptix/http: ReadAll(resp.Body) failed. URL: %s", r.Request.URL.String())
+	if r.Body != nil {
+		body, err := readLimited(r.Body, limit)
+		r.Body.Close()
+		if err != nil {
+			return nil, errors.Wrapf(err, "cryptix/http: reading error body failed. URL: %s", r.Request.URL.String())
+		}
+		errorResponse.Body = body
+		if limit >= 0 && int64(len(body)) > limit {
+			errorResponse.Body = body[:limit]
+			errorResponse.Truncated = true
+		}
+	}
+
+	errorResponse.RetryAfter = parseRetryAfter(r.Header.Get("Retry-After"), time.Now())
+	if !errorResponse.Truncated {
+		errorResponse.decodeBody()
+	}
+
+	return errorResponse, nil
+}
+
+// readLimited reads up to limit+1 bytes so that the caller can tell if there was more.
+// A negative limit reads everything.
+func readLimited(rd io.Reader, limit int64) ([]byte, error) {
+	if limit < 0 {
+		return ioutil.ReadAll(rd)
+	}
+	return ioutil.ReadAll(io.LimitReader(rd, limit+1))
+}
+
+// decodeBody fills the typed fields from JSON bodies, anything else is left alone
+func (r *ErrorResponse) decodeBody() {
+	ct, _, _ := mime.ParseMediaType(r.Response.Header.Get("Content-Type"))
+	if ct != "application/json" && ct != "application/problem+json" && !strings.HasSuffix(ct, "+json") {
+		return
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(r.Body, &fields); err != nil {
+		return
 	}
+	r.Details = fields
 
-	return errorResponse
+	r.Type = stringField(fields, "type")
+	r.Title = stringField(fields, "title")
+	r.Detail = stringField(fields, "detail")
+	r.Instance = stringField(fields, "instance")
+
+	r.Code = stringField(fields, "code", "error_code", "errorCode")
+	r.Message = stringField(fields, "message", "error_description", "msg")
+
+	switch e := fields["error"].(type) {
+	case string:
+		if r.Message == "" {
+			r.Message = e
+		} else if r.Code == "" {
+			r.Code = e
+		}
+	case map[string]interface{}:
+		if r.Code == "" {
+			r.Code = stringField(e, "code", "type")
+		}
+		if r.Message == "" {
+			r.Message = stringField(e, "message", "detail")
+		}
+	}
+}
+
+// stringField returns the first of keys that is set, numbers are formatted as well
+func stringField(fields map[string]interface{}, keys ...string) string {
+	for _, k := range keys {
+		switch v := fields[k].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+	return ""
+}
+
+const maxRetryAfterSecs = int64(math.MaxInt64 / time.Second)
+
+// parseRetryAfter understands both forms of the header: delay seconds and an HTTP date
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return 0
+	}
+	// out of range values are clamped by ParseInt and then to what a time.Duration can hold
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil || stderrors.Is(err, strconv.ErrRange) {
+		if secs < 0 {
+			return 0
+		}
+		if secs > maxRetryAfterSecs {
+			secs = maxRetryAfterSecs
+		}
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := t.Sub(now); d > 0 {
+			return d
+		}
+	}
+	return 0
 }
@@ -2,9 +2,14 @@ package http
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestCheck_valid(t *testing.T) {
@@ -40,3 +45,188 @@ func TestCheck_500(t *testing.T) {
 		t.Error("marked invalid response as OK")
 	}
 }
+
+func newErrorResponse(status int, contentType, body string) *http.Response {
+	req, _ := http.NewRequest("GET", "http://example.com/api", nil)
+	h := make(http.Header)
+	if contentType != "" {
+		h.Set("Content-Type", contentType)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     h,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func TestCheck_problemJSON(t *testing.T) {
+	resp := newErrorResponse(403, "application/problem+json", `{
+		"type": "https://example.com/probs/out-of-credit",
+		"title": "You do not have enough credit.",
+		"status": 403,
+		"detail": "Your current balance is 30, but that costs 50.",
+		"instance": "/account/12345/msgs/abc",
+		"balance": 30
+	}`)
+
+	err := CheckResponse(resp)
+	var er *ErrorResponse
+	if !errors.As(err, &er) {
+		t.Fatalf("expected *ErrorResponse, got %T", err)
+	}
+	if er.Type != "https://example.com/probs/out-of-credit" || er.Title != "You do not have enough credit." ||
+		er.Detail != "Your current balance is 30, but that costs 50." || er.Instance != "/account/12345/msgs/abc" {
+		t.Errorf("problem not decoded: %+v", er)
+	}
+	if er.Details["balance"] != float64(30) {
+		t.Errorf("extension member missing: %v", er.Details)
+	}
+	if !strings.Contains(er.Error(), "Your current balance is 30") {
+		t.Errorf("wrong message: %s", er.Error())
+	}
+}
+
+func TestCheck_errorShapes(t *testing.T) {
+	tcases := []struct {
+		body        string
+		code, msg   string
+		contentType string
+	}{
+		{`{"error": "invalid_grant", "error_description": "token expired"}`, "invalid_grant", "token expired", "application/json"},
+		{`{"error": "something broke"}`, "", "something broke", "application/json; charset=utf-8"},
+		{`{"code": 42, "message": "nope"}`, "42", "nope", "application/json"},
+		{`{"error": {"code": "E_LIMIT", "message": "slow down"}}`, "E_LIMIT", "slow down", "application/vnd.api+json"},
+		{`not json`, "", "", "application/json"},
+		{`{"error": "ignored"}`, "", "", "text/plain"},
+	}
+
+	for i, tc := range tcases {
+		err := CheckResponse(newErrorResponse(400, tc.contentType, tc.body))
+		var er *ErrorResponse
+		if !errors.As(err, &er) {
+			t.Fatalf("%d: expected *ErrorResponse, got %T", i, err)
+		}
+		if er.Code != tc.code || er.Message != tc.msg {
+			t.Errorf("%d: got code %q message %q", i, er.Code, er.Message)
+		}
+		if string(er.Body) != tc.body {
+			t.Errorf("%d: body not kept: %q", i, er.Body)
+		}
+	}
+}
+
+func TestCheck_sentinels(t *testing.T) {
+	tcases := []struct {
+		status int
+		check  func(error) bool
+	}{
+		{404, IsNotFound},
+		{401, IsUnauthorized},
+		{429, IsRateLimited},
+		{500, IsServerError},
+		{503, IsServerError},
+	}
+
+	for _, tc := range tcases {
+		err := CheckResponse(newErrorResponse(tc.status, "", ""))
+		if !tc.check(err) {
+			t.Errorf("%d: check failed", tc.status)
+		}
+	}
+
+	err := CheckResponse(newErrorResponse(400, "", ""))
+	for _, sentinel := range []error{ErrNotFound, ErrUnauthorized, ErrRateLimited, ErrServer} {
+		if errors.Is(err, sentinel) {
+			t.Errorf("400 matches %s", sentinel)
+		}
+	}
+
+	wrapped := fmt.Errorf("fetching profile: %w", CheckResponse(newErrorResponse(404, "", "")))
+	if !IsNotFound(wrapped) {
+		t.Error("wrapped error not matched")
+	}
+}
+
+func TestCheck_retryAfter(t *testing.T) {
+	resp := newErrorResponse(429, "", "")
+	resp.Header.Set("Retry-After", "120")
+	var er *ErrorResponse
+	if !errors.As(CheckResponse(resp), &er) || er.RetryAfter != 2*time.Minute {
+		t.Fatalf("wrong retry after: %+v", er)
+	}
+
+	now := time.Date(2015, 10, 21, 7, 28, 0, 0, time.UTC)
+	if d := parseRetryAfter("Wed, 21 Oct 2015 07:28:30 GMT", now); d != 30*time.Second {
+		t.Errorf("wrong date delay: %s", d)
+	}
+	for _, v := range []string{"9300000000", "9223372036854775807", "99999999999999999999"} {
+		if d := parseRetryAfter(v, now); d != time.Duration(maxRetryAfterSecs)*time.Second {
+			t.Errorf("%q: not clamped, got %s", v, d)
+		}
+	}
+	for _, v := range []string{"", "-5", "-99999999999999999999", "soon", "Wed, 21 Oct 2015 07:27:00 GMT"} {
+		if d := parseRetryAfter(v, now); d != 0 {
+			t.Errorf("%q: expected zero, got %s", v, d)
+		}
+	}
+}
+
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (c *closeRecorder) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestCheck_limit(t *testing.T) {
+	body := &closeRecorder{Reader: strings.NewReader(strings.Repeat("x", 100))}
+	resp := newErrorResponse(500, "", "")
+	resp.Body = body
+
+	var er *ErrorResponse
+	if !errors.As(CheckResponseLimit(resp, 10), &er) {
+		t.Fatal("expected *ErrorResponse")
+	}
+	if !er.Truncated || string(er.Body) != strings.Repeat("x", 10) {
+		t.Errorf("not truncated: %v %q", er.Truncated, er.Body)
+	}
+	if !body.closed {
+		t.Error("body not closed")
+	}
+
+	resp = newErrorResponse(500, "", "exactly10!")
+	if !errors.As(CheckResponseLimit(resp, 10), &er) {
+		t.Fatal("expected *ErrorResponse")
+	}
+	if er.Truncated || string(er.Body) != "exactly10!" {
+		t.Errorf("wrongly truncated: %v %q", er.Truncated, er.Body)
+	}
+}
+
+func TestCheck_replayable(t *testing.T) {
+	resp := newErrorResponse(400, "application/json", `{"message": "bad input"}`)
+	err := CheckResponseReplayable(resp)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	again, rerr := ioutil.ReadAll(resp.Body)
+	if rerr != nil {
+		t.Fatal(rerr)
+	}
+	if string(again) != `{"message": "bad input"}` {
+		t.Errorf("body not restored: %q", again)
+	}
+
+	resp = newErrorResponse(200, "", "fine")
+	if err := CheckResponseReplayable(resp); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := ioutil.ReadAll(resp.Body); string(ok) != "fine" {
+		t.Errorf("2xx body touched: %q", ok)
+	}
+}
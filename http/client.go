@@ -0,0 +1,211 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+
+	"go.mindeco.de/backoff"
+)
+
+// Client wraps an *http.Client for talking to APIs.
+//
+// Every response goes through CheckResponse, so non-2xx statuses come back as *ErrorResponse.
+// Idempotent requests (GET, HEAD, OPTIONS, PUT, DELETE, TRACE or any request with an Idempotency-Key header)
+// are retried on timeouts, temporary network errors, connection resets, 429 and 5xx responses (except 501),
+// waiting for the backoff policy or the Retry-After of the response, whichever is longer.
+// Nothing is retried once the context of the request is done.
+type Client struct {
+	hc *http.Client
+
+	policy  backoff.Backoff
+	retries int
+	timeout time.Duration
+
+	log kitlog.Logger
+}
+
+// NewClient returns a Client. Without options it uses http.DefaultClient, backoff.Default,
+// three retries, no timeout and doesn't log.
+func NewClient(opts ...ClientOption) (*Client, error) {
+	c := Client{retries: 3}
+	for i, o := range opts {
+		if err := o(&c); err != nil {
+			return nil, errors.Wrapf(err, "cryptix/http: client option %d failed", i)
+		}
+	}
+
+	if c.hc == nil {
+		c.hc = http.DefaultClient
+	}
+	if c.policy == nil {
+		c.policy = backoff.Default
+	}
+	if c.log == nil {
+		c.log = kitlog.NewNopLogger()
+	}
+	return &c, nil
+}
+
+// Do sends the request, retrying it if it is idempotent and failed temporarily.
+// A request with a body is only retried if req.GetBody is set, which http.NewRequest does for the common readers.
+//
+// If the client has a timeout and the request context has no deadline, the timeout covers all attempts
+// and reading the response body.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	var cancel context.CancelFunc = func() {}
+	if _, has := ctx.Deadline(); !has && c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		req = req.WithContext(ctx)
+	}
+
+	resp, err := c.do(ctx, req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (c *Client) do(ctx context.Context, req *http.Request) (*http.Response, error) {
+	retry := c.retries > 0 && isIdempotent(req) && (req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, errors.Wrap(err, "cryptix/http: failed to replay request body")
+			}
+			req.Body = body
+		}
+
+		start := time.Now()
+		resp, err := c.hc.Do(req)
+		if err == nil {
+			err = CheckResponse(resp)
+		}
+		c.log.Log("event", "http attempt", "method", req.Method, "url", req.URL, "attempt", attempt+1, "took", time.Since(start), "err", err)
+		if err == nil {
+			return resp, nil
+		}
+
+		if !retry || attempt >= c.retries || !temporary(ctx, err) {
+			return nil, err
+		}
+
+		wait := c.policy.Duration(attempt)
+		var er *ErrorResponse
+		if stderrors.As(err, &er) && er.RetryAfter > wait {
+			wait = er.RetryAfter
+		}
+		if dl, has := ctx.Deadline(); has && time.Until(dl) < wait {
+			return nil, err
+		}
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, err
+		case <-t.C:
+		}
+	}
+}
+
+// GetJSON fetches url and decodes the JSON response into out
+func (c *Client) GetJSON(ctx context.Context, url string, out interface{}) error {
+	return c.DoJSON(ctx, http.MethodGet, url, nil, out)
+}
+
+// PostJSON sends in as JSON to url and decodes the response into out
+func (c *Client) PostJSON(ctx context.Context, url string, in, out interface{}) error {
+	return c.DoJSON(ctx, http.MethodPost, url, in, out)
+}
+
+// DoJSON sends a request with in encoded as JSON, unless it is nil, and decodes the response into out, unless it is nil.
+func (c *Client) DoJSON(ctx context.Context, method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return errors.Wrap(err, "cryptix/http: failed to encode request body")
+		}
+		body = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return errors.Wrap(err, "cryptix/http: failed to create request")
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+		return err
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return errors.Wrapf(err, "cryptix/http: failed to decode response of %s %s", method, url)
+	}
+	return nil
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete, http.MethodTrace:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+// temporary decides if a failed attempt is worth repeating
+func temporary(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var er *ErrorResponse
+	if stderrors.As(err, &er) {
+		c := er.Response.StatusCode
+		return c == http.StatusTooManyRequests || (c >= 500 && c != http.StatusNotImplemented)
+	}
+
+	// transport errors: bad URLs or TLS verification failures won't get better
+	if stderrors.Is(err, syscall.ECONNRESET) {
+		return true
+	}
+	var ne net.Error
+	return stderrors.As(err, &ne) && (ne.Timeout() || ne.Temporary())
+}
+
+// cancelBody releases the timeout context once the body was read
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,69 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+
+	"go.mindeco.de/backoff"
+)
+
+// ClientOption is a function that changes a Client during initialization
+type ClientOption func(*Client) error
+
+// SetHTTPClient sets the client that does the actual requests
+func SetHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) error {
+		if hc == nil {
+			return errors.New("http client can't be nil")
+		}
+		c.hc = hc
+		return nil
+	}
+}
+
+// SetBackoff sets the policy to wait between attempts
+func SetBackoff(b backoff.Backoff) ClientOption {
+	return func(c *Client) error {
+		if b == nil {
+			return errors.New("backoff policy can't be nil")
+		}
+		c.policy = b
+		return nil
+	}
+}
+
+// SetRetries sets how often a failed request is repeated. Zero disables retries.
+func SetRetries(n int) ClientOption {
+	return func(c *Client) error {
+		if n < 0 {
+			return errors.New("retries can't be negative")
+		}
+		c.retries = n
+		return nil
+	}
+}
+
+// SetTimeout sets the timeout for requests whose context has no deadline
+func SetTimeout(d time.Duration) ClientOption {
+	return func(c *Client) error {
+		if d <= 0 {
+			return errors.New("timeout needs to be positive")
+		}
+		c.timeout = d
+		return nil
+	}
+}
+
+// SetClientLogger sets the logger for request attempts
+func SetClientLogger(l kitlog.Logger) ClientOption {
+	return func(c *Client) error {
+		if l == nil {
+			return errors.New("logger can't be nil")
+		}
+		c.log = l
+		return nil
+	}
+}
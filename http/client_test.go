@@ -0,0 +1,256 @@
+package http
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"go.mindeco.de/backoff"
+)
+
+var noWait = backoff.IncreasePolicy{Millis: []int{0}}
+
+func newTestClient(t *testing.T, opts ...ClientOption) *Client {
+	opts = append([]ClientOption{
+		SetBackoff(noWait),
+		SetClientLogger(testLogger(t)),
+	}, opts...)
+	c, err := NewClient(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClient_retry(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) < 3 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"name": "test"}`))
+	}))
+	defer srv.Close()
+
+	var out struct{ Name string }
+	if err := newTestClient(t).GetJSON(context.Background(), srv.URL, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "test" || hits != 3 {
+		t.Errorf("got %+v after %d hits", out, hits)
+	}
+}
+
+func TestClient_giveUp(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Error(w, "broken", http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	err := newTestClient(t, SetRetries(2)).GetJSON(context.Background(), srv.URL, nil)
+	if !IsServerError(err) {
+		t.Fatalf("expected server error, got %v", err)
+	}
+	if hits != 3 {
+		t.Errorf("expected 3 attempts, got %d", hits)
+	}
+}
+
+func TestClient_noRetry(t *testing.T) {
+	tcases := []struct {
+		method string
+		status int
+	}{
+		{"POST", http.StatusServiceUnavailable},
+		{"GET", http.StatusNotFound},
+		{"GET", http.StatusNotImplemented},
+	}
+
+	for _, tc := range tcases {
+		var hits int32
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&hits, 1)
+			w.WriteHeader(tc.status)
+		}))
+
+		err := newTestClient(t).DoJSON(context.Background(), tc.method, srv.URL, map[string]int{"a": 1}, nil)
+		if err == nil {
+			t.Errorf("%s %d: expected error", tc.method, tc.status)
+		}
+		if hits != 1 {
+			t.Errorf("%s %d: expected one attempt, got %d", tc.method, tc.status, hits)
+		}
+		srv.Close()
+	}
+}
+
+func TestClient_replayBody(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var in struct{ N int }
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.N != 23 {
+			http.Error(w, "bad body", http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Content-Type") != "application/json" {
+			http.Error(w, "bad content type", http.StatusBadRequest)
+			return
+		}
+		if atomic.AddInt32(&hits, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]int{"N": in.N + 1})
+	}))
+	defer srv.Close()
+
+	var out struct{ N int }
+	err := newTestClient(t).DoJSON(context.Background(), "PUT", srv.URL, map[string]int{"N": 23}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.N != 24 || hits != 2 {
+		t.Errorf("got %+v after %d hits", out, hits)
+	}
+}
+
+func TestClient_retryAfterPastDeadline(t *testing.T) {
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Retry-After", "3600")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	err := newTestClient(t, SetTimeout(time.Second)).GetJSON(context.Background(), srv.URL, nil)
+	if !IsRateLimited(err) {
+		t.Fatalf("expected rate limit error, got %v", err)
+	}
+	if hits != 1 || time.Since(start) > 500*time.Millisecond {
+		t.Errorf("should give up right away, took %s for %d hits", time.Since(start), hits)
+	}
+}
+
+func TestClient_timeout(t *testing.T) {
+	block := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-block:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(block)
+
+	c := newTestClient(t, SetTimeout(50*time.Millisecond))
+	err := c.GetJSON(context.Background(), srv.URL, nil)
+	if err == nil {
+		t.Fatal("expected timeout")
+	}
+
+	// the body can still be read after Do returned
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer fast.Close()
+
+	req, err := http.NewRequest("GET", fast.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil || string(body) != "ok" {
+		t.Errorf("got %q %v", body, err)
+	}
+}
+
+func TestClient_noRetryTLS(t *testing.T) {
+	var conns int32
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	srv.Config.ConnState = func(_ net.Conn, s http.ConnState) {
+		if s == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	srv.StartTLS()
+	defer srv.Close()
+
+	// the default client doesn't trust the test certificate
+	err := newTestClient(t, SetRetries(3)).GetJSON(context.Background(), srv.URL, nil)
+	if err == nil {
+		t.Fatal("expected certificate error")
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("expected 1 connection, got %d", n)
+	}
+}
+
+func TestTemporary(t *testing.T) {
+	ctx := context.Background()
+	urlErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://example.com", Err: err}
+	}
+
+	tcases := []struct {
+		err  error
+		want bool
+	}{
+		{urlErr(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), true},
+		{urlErr(&net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), false},
+		{urlErr(timeoutError{}), true},
+		{urlErr(x509.UnknownAuthorityError{}), false},
+		{urlErr(errors.New("unsupported protocol scheme")), false},
+		{urlErr(context.Canceled), false},
+		{CheckResponse(newErrorResponse(503, "", "")), true},
+		{CheckResponse(newErrorResponse(400, "", "")), false},
+	}
+	for i, tc := range tcases {
+		if got := temporary(ctx, tc.err); got != tc.want {
+			t.Errorf("%d: %v: got %v", i, tc.err, got)
+		}
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if temporary(canceled, urlErr(timeoutError{})) {
+		t.Error("retry after the context was canceled")
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestNewClient_invalid(t *testing.T) {
+	for i, o := range []ClientOption{SetHTTPClient(nil), SetBackoff(nil), SetRetries(-1), SetTimeout(0), SetClientLogger(nil)} {
+		if _, err := NewClient(o); err == nil {
+			t.Errorf("%d: expected error", i)
+		}
+	}
+}
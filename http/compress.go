@@ -0,0 +1,338 @@
+package http
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultSkipCompressTypes are content types which are already compressed and not worth compressing again.
+// Entries ending in a slash match the whole type, like all images.
+var DefaultSkipCompressTypes = []string{
+	"image/", "audio/", "video/",
+	"font/woff", "font/woff2",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-bzip2",
+	"application/x-xz", "application/x-7z-compressed", "application/x-rar-compressed",
+	"application/pdf", "application/wasm",
+}
+
+// compressible images, which would otherwise be caught by "image/"
+var compressibleImages = map[string]bool{
+	"image/svg+xml": true,
+	"image/bmp":     true,
+	"image/x-icon":  true,
+}
+
+type compressor struct {
+	level   int
+	minSize int
+	skip    []string
+
+	gzipPool  sync.Pool
+	flatePool sync.Pool
+}
+
+// CompressOption is a function that changes the Compress middleware during initialization
+type CompressOption func(*compressor) error
+
+// SetCompressionLevel sets the level for gzip and deflate, see compress/flate
+func SetCompressionLevel(level int) CompressOption {
+	return func(c *compressor) error {
+		if level < flate.HuffmanOnly || level > flate.BestCompression {
+			return errors.New("invalid compression level")
+		}
+		c.level = level
+		return nil
+	}
+}
+
+// SetMinCompressSize sets the size a response needs to have to be compressed
+func SetMinCompressSize(n int) CompressOption {
+	return func(c *compressor) error {
+		if n < 0 {
+			return errors.New("minimum size can't be negative")
+		}
+		c.minSize = n
+		return nil
+	}
+}
+
+// SkipContentTypes replaces DefaultSkipCompressTypes
+func SkipContentTypes(types ...string) CompressOption {
+	return func(c *compressor) error {
+		c.skip = types
+		return nil
+	}
+}
+
+// Compress compresses responses with gzip or deflate, depending on the Accept-Encoding of the request.
+// Brotli isn't offered since there is no implementation in the standard library.
+//
+// The first bytes of a response are buffered to decide if compressing is worth it:
+// small bodies, bodies without content, partial content, responses with a Content-Encoding
+// and the types in DefaultSkipCompressTypes are sent as they are.
+// Flushing the response forces the decision and flushes the compressor as well.
+// Upgrade requests, like websockets, are passed through untouched.
+func Compress(opts ...CompressOption) (MiddlewareFunc, error) {
+	c := &compressor{
+		level:   gzip.DefaultCompression,
+		minSize: 1024,
+		skip:    DefaultSkipCompressTypes,
+	}
+	for _, o := range opts {
+		if err := o(c); err != nil {
+			return nil, err
+		}
+	}
+	return c.middleware, nil
+}
+
+func (c *compressor) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		addVary(w.Header(), "Accept-Encoding")
+
+		enc := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+		if enc == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, c: c, enc: enc, status: http.StatusOK}
+		defer cw.Close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+func (c *compressor) skipType(ct string) bool {
+	ct = strings.ToLower(strings.TrimSpace(strings.Split(ct, ";")[0]))
+	if compressibleImages[ct] {
+		return false
+	}
+	for _, s := range c.skip {
+		if ct == s || (strings.HasSuffix(s, "/") && strings.HasPrefix(ct, s)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *compressor) getWriter(enc string, w io.Writer) io.WriteCloser {
+	switch enc {
+	case "gzip":
+		if gz, ok := c.gzipPool.Get().(*gzip.Writer); ok {
+			gz.Reset(w)
+			return gz
+		}
+		gz, _ := gzip.NewWriterLevel(w, c.level) // level is checked by the option
+		return gz
+	default:
+		if fw, ok := c.flatePool.Get().(*flate.Writer); ok {
+			fw.Reset(w)
+			return fw
+		}
+		fw, _ := flate.NewWriter(w, c.level)
+		return fw
+	}
+}
+
+func (c *compressor) putWriter(wc io.WriteCloser) {
+	switch v := wc.(type) {
+	case *gzip.Writer:
+		c.gzipPool.Put(v)
+	case *flate.Writer:
+		c.flatePool.Put(v)
+	}
+}
+
+// negotiateEncoding picks gzip or deflate from an Accept-Encoding header, preferring gzip on equal weights.
+// * stands for the encodings that are not listed explicitly.
+func negotiateEncoding(header string) string {
+	weights := make(map[string]float64)
+	wildcard := -1.0
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		q := 1.0
+		for _, p := range fields[1:] {
+			p = strings.TrimSpace(p)
+			if strings.HasPrefix(p, "q=") {
+				v, err := strconv.ParseFloat(p[2:], 64)
+				if err != nil {
+					v = 0
+				}
+				q = v
+			}
+		}
+		if name == "*" {
+			wildcard = q
+		} else {
+			weights[name] = q
+		}
+	}
+
+	var best string
+	bestQ := 0.0
+	for _, name := range []string{"gzip", "deflate"} {
+		q, listed := weights[name]
+		if !listed {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = name, q
+		}
+	}
+	return best
+}
+
+// addVary adds value to the Vary header, unless it is already listed
+func addVary(h http.Header, value string) {
+	for _, v := range h["Vary"] {
+		for _, tok := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(tok), value) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", value)
+}
+
+// compressWriter buffers the start of the response until it is clear whether it should be compressed
+type compressWriter struct {
+	http.ResponseWriter
+	c   *compressor
+	enc string
+
+	status      int
+	wroteHeader bool
+
+	decided bool
+	buf     []byte
+	cw      io.WriteCloser
+}
+
+func (w *compressWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.status = code
+	w.wroteHeader = true
+	if !bodyAllowed(code) {
+		w.decide(false)
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.decided {
+		if w.cw != nil {
+			return w.cw.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.c.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends what is buffered and flushes the compressor and the wrapped writer
+func (w *compressWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		w.decide(true)
+	}
+	if f, ok := w.cw.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Close finishes the response, it is called by the middleware once the handler returned
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if !w.wroteHeader && len(w.buf) == 0 {
+			// nothing was written, leave the defaults to net/http
+			return nil
+		}
+		if err := w.decide(len(w.buf) >= w.c.minSize); err != nil {
+			return err
+		}
+	}
+	if w.cw == nil {
+		return nil
+	}
+	err := w.cw.Close()
+	w.c.putWriter(w.cw)
+	w.cw = nil
+	return err
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header and the buffered data, compressed if possible and big enough
+func (w *compressWriter) decide(bigEnough bool) error {
+	w.decided = true
+	h := w.Header()
+
+	if h.Get("Content-Type") == "" && len(w.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(w.buf))
+	}
+
+	compress := bigEnough &&
+		bodyAllowed(w.status) &&
+		w.status != http.StatusPartialContent &&
+		h.Get("Content-Encoding") == "" &&
+		h.Get("Content-Range") == "" &&
+		!w.c.skipType(h.Get("Content-Type"))
+	if cl := h.Get("Content-Length"); compress && cl != "" {
+		if n, err := strconv.Atoi(cl); err == nil && n < w.c.minSize {
+			compress = false
+		}
+	}
+
+	if compress {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", w.enc)
+		w.cw = w.c.getWriter(w.enc, w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.cw != nil {
+		_, err = w.cw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+func bodyAllowed(status int) bool {
+	return status >= 200 && status != http.StatusNoContent && status != http.StatusNotModified
+}
@@ -0,0 +1,290 @@
+package http
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+
+	"go.mindeco.de/http/render"
+)
+
+func newCompressHandler(t *testing.T, h http.HandlerFunc, opts ...CompressOption) http.Handler {
+	mw, err := Compress(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mw(h)
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tcases := map[string]string{
+		"":                          "",
+		"gzip":                      "gzip",
+		"deflate, gzip":             "gzip",
+		"deflate":                   "deflate",
+		"gzip;q=0.5, deflate":       "deflate",
+		"gzip;q=0, deflate;q=0":     "",
+		"br, identity":              "",
+		"*":                         "gzip",
+		"GZIP;q=0.8, deflate;q=0.8": "gzip",
+		"gzip;q=0, *":               "deflate",
+		"gzip;q=0, deflate;q=0, *":  "",
+		"*;q=0.5, deflate":          "deflate",
+	}
+	for in, want := range tcases {
+		if got := negotiateEncoding(in); got != want {
+			t.Errorf("%q: got %q", in, got)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	big := strings.Repeat(`{"hello": "world"},`, 200)
+
+	tcases := []struct {
+		accept      string
+		contentType string
+		body        string
+		wantEnc     string
+	}{
+		{"gzip", "application/json", big, "gzip"},
+		{"deflate", "application/json", big, "deflate"},
+		{"gzip", "application/json", "{}", ""},
+		{"", "application/json", big, ""},
+		{"gzip", "image/png", big, ""},
+		{"gzip", "image/svg+xml", big, "gzip"},
+		{"gzip", "", "<html><body>" + big, "gzip"},
+	}
+
+	for i, tc := range tcases {
+		h := newCompressHandler(t, func(w http.ResponseWriter, r *http.Request) {
+			if tc.contentType != "" {
+				w.Header().Set("Content-Type", tc.contentType)
+			}
+			w.Header().Set("Content-Length", "99999")
+			// write in small pieces to exercise the buffering
+			for j := 0; j < len(tc.body); j += 100 {
+				end := j + 100
+				if end > len(tc.body) {
+					end = len(tc.body)
+				}
+				io.WriteString(w, tc.body[j:end])
+			}
+		})
+
+		req := httptest.NewRequest("GET", "/", nil)
+		if tc.accept != "" {
+			req.Header.Set("Accept-Encoding", tc.accept)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if got := rec.Header().Get("Content-Encoding"); got != tc.wantEnc {
+			t.Errorf("%d: wrong encoding %q", i, got)
+			continue
+		}
+		if rec.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("%d: missing vary", i)
+		}
+
+		var rd io.Reader = rec.Body
+		switch tc.wantEnc {
+		case "gzip":
+			gz, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			rd = gz
+			if rec.Header().Get("Content-Length") != "" {
+				t.Errorf("%d: stale content length", i)
+			}
+		case "deflate":
+			rd = flate.NewReader(rec.Body)
+		}
+		got, err := ioutil.ReadAll(rd)
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		if string(got) != tc.body {
+			t.Errorf("%d: body mismatch", i)
+		}
+		if tc.contentType == "" && !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+			t.Errorf("%d: content type not sniffed: %q", i, rec.Header().Get("Content-Type"))
+		}
+	}
+}
+
+// gunzip checks that rec holds a gzip encoded body and returns it decoded
+func gunzip(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	if enc := rec.Header().Get("Content-Encoding"); enc != "gzip" {
+		t.Fatalf("wrong encoding %q", enc)
+	}
+	gz, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := ioutil.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(body)
+}
+
+func TestCompress_render(t *testing.T) {
+	r, err := render.New(http.Dir("render/tests"),
+		render.AddTemplates("test1.tmpl"),
+		render.SetLogger(kitlog.NewNopLogger()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := func(w http.ResponseWriter, req *http.Request) {
+		if err := r.Render(w, req, "test1.tmpl", http.StatusCreated, nil); err != nil {
+			t.Error(err)
+		}
+	}
+
+	plain := httptest.NewRecorder()
+	h(plain, httptest.NewRequest("GET", "/", nil))
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	newCompressHandler(t, h, SetMinCompressSize(0)).ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Errorf("wrong status %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("wrong content type %q", ct)
+	}
+	if body := gunzip(t, rec); body != plain.Body.String() {
+		t.Errorf("body mismatch:\n%s", body)
+	}
+}
+
+func TestCompress_binary(t *testing.T) {
+	csv := strings.Repeat("id,name\n23,test\n", 200)
+	tcases := []struct {
+		contentType string
+		gzip        bool
+	}{
+		{"text/csv", true},
+		{"application/zip", false},
+	}
+	for _, tc := range tcases {
+		h := render.Binary(func(w http.ResponseWriter, req *http.Request) error {
+			w.Header().Set("Content-Type", tc.contentType)
+			_, err := io.WriteString(w, csv)
+			return err
+		})
+
+		req := httptest.NewRequest("GET", "/export", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		newCompressHandler(t, h.ServeHTTP).ServeHTTP(rec, req)
+
+		if rec.Header().Get("Content-Description") != "File Transfer" {
+			t.Errorf("%s: headers of Binary lost", tc.contentType)
+		}
+		var body string
+		if tc.gzip {
+			body = gunzip(t, rec)
+		} else {
+			if enc := rec.Header().Get("Content-Encoding"); enc != "" {
+				t.Errorf("%s: compressed with %q", tc.contentType, enc)
+			}
+			body = rec.Body.String()
+		}
+		if body != csv {
+			t.Errorf("%s: body mismatch", tc.contentType)
+		}
+	}
+}
+
+func TestCompress_noBody(t *testing.T) {
+	for _, status := range []int{http.StatusNoContent, http.StatusNotModified} {
+		h := newCompressHandler(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != status || rec.Header().Get("Content-Encoding") != "" || rec.Body.Len() != 0 {
+			t.Errorf("%d: got %d %q", status, rec.Code, rec.Body.String())
+		}
+	}
+
+	// already encoded
+	h := newCompressHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		w.Write([]byte(strings.Repeat("x", 2048)))
+	})
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Content-Encoding") != "br" || rec.Body.Len() != 2048 {
+		t.Errorf("encoded body touched")
+	}
+}
+
+func TestCompress_flush(t *testing.T) {
+	next := make(chan struct{})
+	h := newCompressHandler(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: one\n\n")
+		w.(http.Flusher).Flush()
+		<-next
+		io.WriteString(w, "data: two\n\n")
+	})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	req, err := http.NewRequest("GET", srv.URL, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Fatalf("not compressed: %v", resp.Header)
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first event arrives before the handler finished
+	first := make([]byte, len("data: one\n\n"))
+	if _, err := io.ReadFull(gz, first); err != nil || string(first) != "data: one\n\n" {
+		t.Fatalf("got %q %v", first, err)
+	}
+	close(next)
+	rest, err := ioutil.ReadAll(gz)
+	if err != nil || string(rest) != "data: two\n\n" {
+		t.Errorf("got %q %v", rest, err)
+	}
+}
+
+func TestCompress_invalidOptions(t *testing.T) {
+	if _, err := Compress(SetCompressionLevel(42)); err == nil {
+		t.Error("invalid level accepted")
+	}
+	if _, err := Compress(SetMinCompressSize(-1)); err == nil {
+		t.Error("negative size accepted")
+	}
+}
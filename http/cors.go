@@ -0,0 +1,231 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type cors struct {
+	anyOrigin bool
+	origins   map[string]bool
+	patterns  [][2]string // prefix and suffix of origins with a wildcard
+
+	methods     map[string]bool
+	methodList  string
+	anyHeader   bool
+	headers     map[string]bool
+	exposed     string
+	credentials bool
+	maxAge      time.Duration
+}
+
+// CORSOption is a function that changes the CORS middleware during initialization
+type CORSOption func(*cors) error
+
+// AllowOrigins sets the origins that may access the resources.
+// "*" allows all of them, a wildcard like "https://*.example.com" allows all subdomains.
+func AllowOrigins(origins ...string) CORSOption {
+	return func(c *cors) error {
+		for _, o := range origins {
+			o = strings.ToLower(o)
+			switch i := strings.Index(o, "*"); {
+			case o == "*":
+				c.anyOrigin = true
+			case i >= 0:
+				if strings.Count(o, "*") > 1 {
+					return errors.New("only one wildcard per origin allowed")
+				}
+				c.patterns = append(c.patterns, [2]string{o[:i], o[i+1:]})
+			default:
+				c.origins[o] = true
+			}
+		}
+		return nil
+	}
+}
+
+// AllowMethods sets the methods that may be used. The default is GET, HEAD and POST.
+func AllowMethods(methods ...string) CORSOption {
+	return func(c *cors) error {
+		c.methods = make(map[string]bool, len(methods))
+		for _, m := range methods {
+			c.methods[strings.ToUpper(m)] = true
+		}
+		return nil
+	}
+}
+
+// AllowHeaders sets the request headers that may be used, in addition to the CORS-safelisted ones.
+// "*" allows all headers a preflight asks for.
+func AllowHeaders(headers ...string) CORSOption {
+	return func(c *cors) error {
+		for _, h := range headers {
+			if h == "*" {
+				c.anyHeader = true
+				continue
+			}
+			c.headers[http.CanonicalHeaderKey(h)] = true
+		}
+		return nil
+	}
+}
+
+// ExposeHeaders sets the response headers that scripts may read
+func ExposeHeaders(headers ...string) CORSOption {
+	return func(c *cors) error {
+		canon := make([]string, len(headers))
+		for i, h := range headers {
+			canon[i] = http.CanonicalHeaderKey(h)
+		}
+		c.exposed = strings.Join(canon, ", ")
+		return nil
+	}
+}
+
+// AllowCredentials lets browsers send cookies and authorization headers along.
+// It can't be combined with AllowOrigins("*"), every allowed origin has to be listed or match a wildcard.
+func AllowCredentials() CORSOption {
+	return func(c *cors) error {
+		c.credentials = true
+		return nil
+	}
+}
+
+// SetMaxAge sets how long browsers may cache the result of a preflight request
+func SetMaxAge(d time.Duration) CORSOption {
+	return func(c *cors) error {
+		if d < 0 {
+			return errors.New("max age can't be negative")
+		}
+		c.maxAge = d
+		return nil
+	}
+}
+
+// CORS answers preflight requests and sets the Access-Control headers for allowed origins.
+// Preflight requests are answered directly and don't reach the next handler,
+// so CORS needs to come before Authorize in a Chain, browsers don't send credentials with them.
+// With credentials the request origin is echoed back, as browsers require.
+func CORS(opts ...CORSOption) (MiddlewareFunc, error) {
+	c := &cors{
+		origins: make(map[string]bool),
+		headers: map[string]bool{
+			"Accept":           true,
+			"Accept-Language":  true,
+			"Content-Language": true,
+			"Content-Type":     true,
+		},
+	}
+	for _, o := range opts {
+		if err := o(c); err != nil {
+			return nil, err
+		}
+	}
+	if !c.anyOrigin && len(c.origins) == 0 && len(c.patterns) == 0 {
+		return nil, errors.New("cryptix/http: CORS needs at least one allowed origin")
+	}
+	if c.anyOrigin && c.credentials {
+		return nil, errors.New("cryptix/http: CORS can't allow credentials for any origin")
+	}
+	if c.methods == nil {
+		AllowMethods(http.MethodGet, http.MethodHead, http.MethodPost)(c)
+	}
+	var ms []string
+	for m := range c.methods {
+		ms = append(ms, m)
+	}
+	sort.Strings(ms)
+	c.methodList = strings.Join(ms, ", ")
+	return c.middleware, nil
+}
+
+func (c *cors) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		h := w.Header()
+		if !c.anyOrigin {
+			addVary(h, "Origin")
+		}
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			c.preflight(w, r, origin)
+			return
+		}
+
+		if origin != "" && c.allowedOrigin(origin) {
+			c.setOrigin(h, origin)
+			if c.exposed != "" {
+				h.Set("Access-Control-Expose-Headers", c.exposed)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (c *cors) preflight(w http.ResponseWriter, r *http.Request, origin string) {
+	h := w.Header()
+	addVary(h, "Access-Control-Request-Method")
+	addVary(h, "Access-Control-Request-Headers")
+
+	method := strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))
+	if origin == "" || !c.allowedOrigin(origin) || !c.methods[method] {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	var requested []string
+	for _, v := range r.Header["Access-Control-Request-Headers"] {
+		for _, hdr := range strings.Split(v, ",") {
+			hdr = http.CanonicalHeaderKey(strings.TrimSpace(hdr))
+			if hdr == "" {
+				continue
+			}
+			if !c.anyHeader && !c.headers[hdr] {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			requested = append(requested, hdr)
+		}
+	}
+
+	c.setOrigin(h, origin)
+	h.Set("Access-Control-Allow-Methods", c.methodList)
+	if len(requested) > 0 {
+		h.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+	if c.maxAge > 0 {
+		h.Set("Access-Control-Max-Age", strconv.Itoa(int(c.maxAge/time.Second)))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *cors) setOrigin(h http.Header, origin string) {
+	if c.anyOrigin {
+		h.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	h.Set("Access-Control-Allow-Origin", origin)
+	if c.credentials {
+		h.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *cors) allowedOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if c.origins[origin] {
+		return true
+	}
+	for _, p := range c.patterns {
+		if len(origin) > len(p[0])+len(p[1]) && strings.HasPrefix(origin, p[0]) && strings.HasSuffix(origin, p[1]) {
+			return true
+		}
+	}
+	return false
+}
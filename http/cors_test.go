@@ -0,0 +1,140 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newCORSHandler(t *testing.T, opts ...CORSOption) http.Handler {
+	mw, err := CORS(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewChain(mw, Authorize("X-Token", "secret")).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+}
+
+func preflight(origin, method, headers string) *http.Request {
+	req := httptest.NewRequest("OPTIONS", "/api", nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return req
+}
+
+func TestCORS_preflight(t *testing.T) {
+	h := newCORSHandler(t,
+		AllowOrigins("https://app.example.com", "https://*.example.org"),
+		AllowMethods("GET", "PUT"),
+		AllowHeaders("X-Token"),
+		AllowCredentials(),
+		SetMaxAge(10*time.Minute),
+	)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, preflight("https://app.example.com", "PUT", "x-token, content-type"))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight rejected: %d", rec.Code)
+	}
+	want := map[string]string{
+		"Access-Control-Allow-Origin":      "https://app.example.com",
+		"Access-Control-Allow-Credentials": "true",
+		"Access-Control-Allow-Methods":     "GET, PUT",
+		"Access-Control-Allow-Headers":     "X-Token, Content-Type",
+		"Access-Control-Max-Age":           "600",
+	}
+	for k, v := range want {
+		if got := rec.Header().Get(k); got != v {
+			t.Errorf("%s: got %q", k, got)
+		}
+	}
+
+	tcases := []struct {
+		origin, method, headers string
+		status                  int
+	}{
+		{"https://eu.example.org", "GET", "", http.StatusNoContent},
+		{"https://example.org", "GET", "", http.StatusForbidden},
+		{"https://evil.com", "GET", "", http.StatusForbidden},
+		{"https://app.example.com", "DELETE", "", http.StatusForbidden},
+		{"https://app.example.com", "GET", "X-Other", http.StatusForbidden},
+	}
+	for i, tc := range tcases {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, preflight(tc.origin, tc.method, tc.headers))
+		if rec.Code != tc.status {
+			t.Errorf("%d: got %d", i, rec.Code)
+		}
+		if tc.status == http.StatusForbidden && rec.Header().Get("Access-Control-Allow-Origin") != "" {
+			t.Errorf("%d: rejected preflight has allow origin", i)
+		}
+	}
+}
+
+func TestCORS_request(t *testing.T) {
+	h := newCORSHandler(t, AllowOrigins("*"), ExposeHeaders("x-request-id"))
+
+	req := httptest.NewRequest("GET", "/api", nil)
+	req.Header.Set("Origin", "https://anywhere.net")
+	req.Header.Set("X-Token", "secret")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != 200 || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("got %d %v", rec.Code, rec.Header())
+	}
+	if rec.Header().Get("Access-Control-Expose-Headers") != "X-Request-Id" {
+		t.Errorf("wrong exposed headers: %v", rec.Header())
+	}
+
+	// unauthorized responses still carry the CORS headers, so that scripts can see the 401
+	req = httptest.NewRequest("GET", "/api", nil)
+	req.Header.Set("Origin", "https://anywhere.net")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || rec.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("got %d %v", rec.Code, rec.Header())
+	}
+
+	// preflights aren't rejected by Authorize
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, preflight("https://anywhere.net", "POST", "content-type"))
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("preflight got %d", rec.Code)
+	}
+}
+
+func TestCORS_credentialsEchoOrigin(t *testing.T) {
+	h := newCORSHandler(t, AllowOrigins("https://*.example.org"), AllowCredentials())
+	req := httptest.NewRequest("GET", "/api", nil)
+	req.Header.Set("Origin", "https://app.example.org")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	hdr := rec.Header()
+	if hdr.Get("Access-Control-Allow-Origin") != "https://app.example.org" || hdr.Get("Access-Control-Allow-Credentials") != "true" || hdr.Get("Vary") != "Origin" {
+		t.Errorf("origin not echoed: %v", hdr)
+	}
+
+	req.Header.Set("Origin", "https://anywhere.net")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if hdr := rec.Header(); hdr.Get("Access-Control-Allow-Origin") != "" || hdr.Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("credentials allowed for unlisted origin: %v", hdr)
+	}
+}
+
+func TestCORS_invalid(t *testing.T) {
+	if _, err := CORS(); err == nil {
+		t.Error("no origins accepted")
+	}
+	if _, err := CORS(AllowOrigins("https://*.*.com")); err == nil {
+		t.Error("double wildcard accepted")
+	}
+	if _, err := CORS(AllowOrigins("*"), AllowCredentials()); err == nil {
+		t.Error("credentials accepted for any origin")
+	}
+}
@@ -0,0 +1,83 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// HTTPError is an error that knows which status it should be answered with.
+// Message is shown to the client, Err is only logged.
+type HTTPError struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+// NewHTTPError returns an HTTPError. If msg is empty, the status text is used as the message.
+func NewHTTPError(status int, msg string, err error) *HTTPError {
+	if msg == "" {
+		msg = http.StatusText(status)
+	}
+	return &HTTPError{Status: status, Message: msg, Err: err}
+}
+
+// BadRequest wraps err with status 400
+func BadRequest(err error) *HTTPError { return NewHTTPError(http.StatusBadRequest, "", err) }
+
+// Unauthorized wraps err with status 401
+func Unauthorized(err error) *HTTPError { return NewHTTPError(http.StatusUnauthorized, "", err) }
+
+// Forbidden wraps err with status 403
+func Forbidden(err error) *HTTPError { return NewHTTPError(http.StatusForbidden, "", err) }
+
+// NotFound wraps err with status 404
+func NotFound(err error) *HTTPError { return NewHTTPError(http.StatusNotFound, "", err) }
+
+// Conflict wraps err with status 409
+func Conflict(err error) *HTTPError { return NewHTTPError(http.StatusConflict, "", err) }
+
+// Internal wraps err with status 500
+func Internal(err error) *HTTPError { return NewHTTPError(http.StatusInternalServerError, "", err) }
+
+func (e *HTTPError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("%d %s", e.Status, e.Message)
+	}
+	return fmt.Sprintf("%d %s: %v", e.Status, e.Message, e.Err)
+}
+
+// Unwrap returns the internal cause
+func (e *HTTPError) Unwrap() error { return e.Err }
+
+// writeError answers with the status and public message of e, as JSON if the client asked for it
+func writeError(w http.ResponseWriter, r *http.Request, e *HTTPError) {
+	if !acceptsJSON(r) {
+		http.Error(w, e.Message, e.Status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(struct {
+		Status int    `json:"status"`
+		Error  string `json:"error"`
+	}{e.Status, e.Message})
+}
+
+// acceptsJSON reports if the Accept header of r names a JSON media type
+func acceptsJSON(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mt, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || params["q"] == "0" {
+			continue
+		}
+		if mt == "application/json" || strings.HasSuffix(mt, "+json") {
+			return true
+		}
+	}
+	return false
+}
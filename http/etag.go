@@ -0,0 +1,195 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oxtoacart/bpool"
+)
+
+// MaxETagBufferSize is the largest response ETag buffers to compute its tag.
+// Larger responses are streamed without one.
+var MaxETagBufferSize = 4 << 20
+
+var etagBufPool = bpool.NewBufferPool(64)
+
+// maxPooledETagBuffer keeps the occasional big response from pinning its buffer in etagBufPool
+const maxPooledETagBuffer = 64 << 10
+
+func putETagBuffer(buf *bytes.Buffer) {
+	if buf.Cap() <= maxPooledETagBuffer {
+		etagBufPool.Put(buf)
+	}
+}
+
+// ETag buffers successful GET responses, tags them with a hash of the body
+// and answers with 304 Not Modified if the request already has that version.
+// Handlers that set an ETag themselves keep theirs. Flushing or big responses disable the buffering.
+// HEAD requests have no body to hash and are passed through, handlers can use CheckConditional for them.
+// weak creates weak tags (W/"..."), which are the right choice if Compress comes before ETag in a Chain.
+func ETag(weak bool) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			buf := etagBufPool.Get()
+			defer putETagBuffer(buf)
+
+			ew := &etagWriter{ResponseWriter: w, buf: buf, status: http.StatusOK}
+			next.ServeHTTP(ew, r)
+			if ew.passthrough {
+				return
+			}
+
+			h := w.Header()
+			etag := h.Get("Etag")
+			if etag == "" {
+				sum := sha256.Sum256(buf.Bytes())
+				etag = `"` + hex.EncodeToString(sum[:16]) + `"`
+				if weak {
+					etag = "W/" + etag
+				}
+				h.Set("Etag", etag)
+			}
+			if CheckConditional(w, r, etag, time.Time{}) {
+				return
+			}
+			w.WriteHeader(ew.status)
+			buf.WriteTo(w)
+		})
+	}
+}
+
+// etagWriter buffers 200 responses and passes everything else through
+type etagWriter struct {
+	http.ResponseWriter
+	buf *bytes.Buffer
+
+	status      int
+	wroteHeader bool
+	passthrough bool
+}
+
+func (w *etagWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+	if code != http.StatusOK {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+func (w *etagWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(p)
+	}
+	if w.buf.Len()+len(p) > MaxETagBufferSize {
+		if err := w.stream(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	return w.buf.Write(p)
+}
+
+// Flush gives up on tagging and sends what was buffered
+func (w *etagWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.passthrough {
+		w.stream()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *etagWriter) stream() error {
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.buf.WriteTo(w.ResponseWriter)
+	return err
+}
+
+// CheckConditional sets the ETag and Last-Modified headers, if they are given, and evaluates
+// If-None-Match and If-Modified-Since. If the client has the current version it answers with
+// 304 Not Modified and returns true, the handler should return then.
+//
+//	if CheckConditional(w, r, `"v42"`, post.Updated) {
+//		return
+//	}
+func CheckConditional(w http.ResponseWriter, r *http.Request, etag string, lastModified time.Time) bool {
+	h := w.Header()
+	if etag != "" {
+		h.Set("Etag", etag)
+	}
+	if !lastModified.IsZero() && !lastModified.Equal(time.Unix(0, 0)) {
+		h.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" || !etagMatch(inm, etag) {
+			return false
+		}
+	} else {
+		ims, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+		if err != nil || lastModified.IsZero() || lastModified.Truncate(time.Second).After(ims) {
+			return false
+		}
+	}
+
+	// see net/http.writeNotModified
+	delete(h, "Content-Type")
+	delete(h, "Content-Length")
+	delete(h, "Content-Encoding")
+	if h.Get("Etag") != "" {
+		delete(h, "Last-Modified")
+	}
+	w.WriteHeader(http.StatusNotModified)
+	return true
+}
+
+// etagMatch does the weak comparison of If-None-Match
+func etagMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// CacheControl sets the Cache-Control header of responses, unless the handler sets its own.
+// Use it per route, e.g. CacheControl("public, max-age=86400") for static assets and CacheControl("no-store") for private pages.
+func CacheControl(value string) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", value)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
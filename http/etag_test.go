@@ -0,0 +1,184 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestETag(t *testing.T) {
+	body := "<html>hello</html>"
+	h := ETag(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/own":
+			w.Header().Set("ETag", `"v1"`)
+			io.WriteString(w, body)
+		default:
+			io.WriteString(w, body)
+		}
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	etag := rec.Header().Get("ETag")
+	if rec.Code != 200 || rec.Body.String() != body || len(etag) != 34 || strings.HasPrefix(etag, "W/") {
+		t.Fatalf("got %d %q etag %q", rec.Code, rec.Body.String(), etag)
+	}
+
+	tcases := []struct {
+		path, inm string
+		status    int
+	}{
+		{"/", etag, http.StatusNotModified},
+		{"/", `"other", ` + etag, http.StatusNotModified},
+		{"/", "W/" + etag, http.StatusNotModified},
+		{"/", `"other"`, http.StatusOK},
+		{"/", "*", http.StatusNotModified},
+		{"/own", `"v1"`, http.StatusNotModified},
+		{"/missing", "*", http.StatusNotFound},
+	}
+	for i, tc := range tcases {
+		req := httptest.NewRequest("GET", tc.path, nil)
+		req.Header.Set("If-None-Match", tc.inm)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%d: got %d", i, rec.Code)
+		}
+		if tc.status == http.StatusNotModified && (rec.Body.Len() != 0 || rec.Header().Get("Content-Type") != "") {
+			t.Errorf("%d: 304 with body or content type", i)
+		}
+	}
+}
+
+func TestETag_weakAndStreaming(t *testing.T) {
+	h := ETag(true)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "part one")
+		if r.URL.Path == "/stream" {
+			w.(http.Flusher).Flush()
+		}
+		io.WriteString(w, ", part two")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if !strings.HasPrefix(rec.Header().Get("ETag"), `W/"`) {
+		t.Errorf("not weak: %q", rec.Header().Get("ETag"))
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/stream", nil))
+	if rec.Header().Get("ETag") != "" || rec.Body.String() != "part one, part two" || !rec.Flushed {
+		t.Errorf("streaming broken: %v %q", rec.Header(), rec.Body.String())
+	}
+
+	old := MaxETagBufferSize
+	MaxETagBufferSize = 10
+	defer func() { MaxETagBufferSize = old }()
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Header().Get("ETag") != "" || rec.Body.String() != "part one, part two" {
+		t.Errorf("big body broken: %v %q", rec.Header(), rec.Body.String())
+	}
+}
+
+func TestETag_head(t *testing.T) {
+	h := ETag(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		if r.Method == http.MethodGet {
+			io.WriteString(w, "<html>hello</html>")
+		}
+	}))
+
+	req := httptest.NewRequest("HEAD", "/", nil)
+	req.Header.Set("If-None-Match", "*")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Header().Get("ETag") != "" {
+		t.Errorf("HEAD got tagged: %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestETag_bigBuffersNotPooled(t *testing.T) {
+	body := strings.Repeat("x", 2*maxPooledETagBuffer)
+	h := ETag(false)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, body)
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Body.String() != body || rec.Header().Get("ETag") == "" {
+		t.Fatalf("wrong response: %v", rec.Header())
+	}
+
+	for i := 0; i < 65; i++ {
+		if c := etagBufPool.Get().Cap(); c > maxPooledETagBuffer {
+			t.Fatalf("pooled buffer with %d bytes", c)
+		}
+	}
+}
+
+func TestCheckConditional_modifiedSince(t *testing.T) {
+	mod := time.Date(2019, 6, 1, 12, 0, 0, 500, time.UTC)
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if CheckConditional(w, r, "", mod) {
+			return
+		}
+		io.WriteString(w, "content")
+	})
+
+	tcases := []struct {
+		ims    string
+		status int
+	}{
+		{"", http.StatusOK},
+		{"Sat, 01 Jun 2019 12:00:00 GMT", http.StatusNotModified},
+		{"Sat, 01 Jun 2019 13:00:00 GMT", http.StatusNotModified},
+		{"Sat, 01 Jun 2019 11:59:59 GMT", http.StatusOK},
+		{"garbage", http.StatusOK},
+	}
+	for i, tc := range tcases {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tc.ims != "" {
+			req.Header.Set("If-Modified-Since", tc.ims)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != tc.status {
+			t.Errorf("%d: got %d", i, rec.Code)
+		}
+		if rec.Header().Get("Last-Modified") != "Sat, 01 Jun 2019 12:00:00 GMT" {
+			t.Errorf("%d: wrong last modified %q", i, rec.Header().Get("Last-Modified"))
+		}
+	}
+
+	// POST is never answered with 304
+	req := httptest.NewRequest("POST", "/", nil)
+	req.Header.Set("If-Modified-Since", "Sat, 01 Jun 2019 13:00:00 GMT")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("POST got %d", rec.Code)
+	}
+}
+
+func TestCacheControl(t *testing.T) {
+	h := NewChain(CacheControl("public, max-age=60")).ThenFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/private" {
+			w.Header().Set("Cache-Control", "no-store")
+		}
+	})
+	for path, want := range map[string]string{"/": "public, max-age=60", "/private": "no-store"} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if got := rec.Header().Get("Cache-Control"); got != want {
+			t.Errorf("%s: got %q", path, got)
+		}
+	}
+}
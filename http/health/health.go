@@ -0,0 +1,252 @@
+/*
+Package health aggregates named checks into liveness and readiness endpoints.
+
+Components register Checks with a Registry. ReadinessHandler runs all of them, LivenessHandler only those
+marked as Liveness, and both answer with a JSON report of the overall status and the details of each check.
+Results are cached for a short time, so that frequent probes don't hammer databases and upstreams.
+
+A supervised child process from the proc package can be checked directly:
+
+	conn, _ := proc.StartStdioProcess("worker", nil)
+	reg.Register(health.Check{Name: "worker", Check: conn.Check, Critical: true, Liveness: true})
+*/
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+
+	"go.mindeco.de/encodedTime"
+)
+
+// Status of a check or a whole report
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded" // only non-critical checks failed
+	StatusFail     = "fail"
+)
+
+// Check is a named health check
+type Check struct {
+	Name string
+
+	// Check returns nil if the component is healthy. It should return once ctx is done.
+	Check func(ctx context.Context) error
+
+	// Timeout overwrites the default timeout of the Registry for this check
+	Timeout time.Duration
+
+	// Critical checks make the report fail, others only degrade it
+	Critical bool
+
+	// Liveness checks are also run by LivenessHandler, all checks are run by ReadinessHandler
+	Liveness bool
+}
+
+// Result is the outcome of a single check
+type Result struct {
+	Status    string               `json:"status"`
+	Error     string               `json:"error,omitempty"`
+	Critical  bool                 `json:"critical"`
+	Took      encodedTime.Duration `json:"took"`
+	CheckedAt time.Time            `json:"checkedAt"`
+}
+
+// Report is the aggregated outcome of a set of checks
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Registry holds the checks of an application
+type Registry struct {
+	log kitlog.Logger
+
+	timeout  time.Duration
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu     sync.Mutex
+	checks []*entry
+	names  map[string]bool
+}
+
+// entry caches the last result of a check. Its lock is held while the check runs,
+// so concurrent requests wait for one run instead of starting their own.
+type entry struct {
+	Check
+
+	mu     sync.Mutex
+	last   Result
+	hasRun bool
+}
+
+// New creates a Registry. By default checks have 5 seconds and results are cached for 2 seconds.
+func New(opts ...Option) (*Registry, error) {
+	r := &Registry{
+		timeout:  5 * time.Second,
+		cacheTTL: 2 * time.Second,
+		now:      time.Now,
+		names:    make(map[string]bool),
+	}
+	for i, o := range opts {
+		if err := o(r); err != nil {
+			return nil, errors.Wrapf(err, "health: option %d failed", i)
+		}
+	}
+	if r.log == nil {
+		r.log = kitlog.NewNopLogger()
+	}
+	return r, nil
+}
+
+// Register adds a check. Names need to be unique.
+func (r *Registry) Register(c Check) error {
+	if c.Name == "" {
+		return errors.New("health: check needs a name")
+	}
+	if c.Check == nil {
+		return errors.Errorf("health: check %s has no function", c.Name)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.names[c.Name] {
+		return errors.Errorf("health: check %s already registered", c.Name)
+	}
+	r.names[c.Name] = true
+	r.checks = append(r.checks, &entry{Check: c})
+	return nil
+}
+
+// Run runs the checks in parallel, or uses their cached results, and aggregates them.
+// If liveness is true, only the Liveness checks are run.
+func (r *Registry) Run(ctx context.Context, liveness bool) Report {
+	r.mu.Lock()
+	var checks []*entry
+	for _, e := range r.checks {
+		if !liveness || e.Liveness {
+			checks = append(checks, e)
+		}
+	}
+	r.mu.Unlock()
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, e := range checks {
+		wg.Add(1)
+		go func(i int, e *entry) {
+			defer wg.Done()
+			results[i] = r.result(ctx, e)
+		}(i, e)
+	}
+	wg.Wait()
+
+	rep := Report{Status: StatusOK, Checks: make(map[string]Result, len(checks))}
+	for i, e := range checks {
+		res := results[i]
+		rep.Checks[e.Name] = res
+		if res.Status == StatusOK {
+			continue
+		}
+		if e.Critical {
+			rep.Status = StatusFail
+		} else if rep.Status == StatusOK {
+			rep.Status = StatusDegraded
+		}
+	}
+	return rep
+}
+
+func (r *Registry) result(ctx context.Context, e *entry) Result {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.hasRun && r.now().Sub(e.last.CheckedAt) < r.cacheTTL {
+		return e.last
+	}
+
+	timeout := r.timeout
+	if e.Timeout > 0 {
+		timeout = e.Timeout
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := r.now()
+	// run in a goroutine so that checks that ignore ctx still time out
+	errc := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				errc <- errors.Errorf("health: check panicked: %v", p)
+			}
+		}()
+		errc <- e.Check.Check(checkCtx)
+	}()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-checkCtx.Done():
+		err = errors.Wrap(checkCtx.Err(), "health: check timed out")
+	}
+
+	res := Result{
+		Status:    StatusOK,
+		Critical:  e.Critical,
+		Took:      encodedTime.Duration(r.now().Sub(start)),
+		CheckedAt: start,
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+		if !e.hasRun || e.last.Status == StatusOK {
+			r.log.Log("event", "check failed", "check", e.Name, "critical", e.Critical, "err", err)
+		}
+	} else if e.hasRun && e.last.Status != StatusOK {
+		r.log.Log("event", "check recovered", "check", e.Name)
+	}
+
+	// a canceled request shouldn't poison the cache for everybody else
+	if ctx.Err() == nil {
+		e.last = res
+		e.hasRun = true
+	}
+	return res
+}
+
+// LivenessHandler serves the report of the Liveness checks, e.g. at /healthz
+func (r *Registry) LivenessHandler() http.Handler {
+	return r.handler(true)
+}
+
+// ReadinessHandler serves the report of all checks, e.g. at /readyz
+func (r *Registry) ReadinessHandler() http.Handler {
+	return r.handler(false)
+}
+
+// handler answers with 200 for ok and degraded reports and 503 for failed ones
+func (r *Registry) handler(liveness bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		rep := r.Run(req.Context(), liveness)
+
+		status := http.StatusOK
+		if rep.Status == StatusFail {
+			status = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(status)
+		if req.Method == http.MethodHead {
+			return
+		}
+		json.NewEncoder(w).Encode(rep)
+	})
+}
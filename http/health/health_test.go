@@ -0,0 +1,174 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.mindeco.de/proc"
+)
+
+func newTestRegistry(t *testing.T, opts ...Option) *Registry {
+	r, err := New(opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func ok(context.Context) error { return nil }
+
+func serve(h http.Handler) (int, Report) {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	var rep Report
+	json.NewDecoder(rec.Body).Decode(&rep)
+	return rec.Code, rep
+}
+
+func TestRegistry(t *testing.T) {
+	r := newTestRegistry(t, SetCacheTTL(0))
+	cacheDown := errors.New("cache down")
+	var dbDown int32
+	r.Register(Check{Name: "self", Check: ok, Critical: true, Liveness: true})
+	r.Register(Check{Name: "cache", Check: func(context.Context) error { return cacheDown }})
+	r.Register(Check{Name: "db", Critical: true, Check: func(context.Context) error {
+		if atomic.LoadInt32(&dbDown) == 1 {
+			return errors.New("connection refused")
+		}
+		return nil
+	}})
+
+	code, rep := serve(r.LivenessHandler())
+	if code != 200 || rep.Status != StatusOK || len(rep.Checks) != 1 {
+		t.Errorf("liveness: %d %+v", code, rep)
+	}
+
+	code, rep = serve(r.ReadinessHandler())
+	if code != 200 || rep.Status != StatusDegraded || len(rep.Checks) != 3 {
+		t.Errorf("readiness: %d %+v", code, rep)
+	}
+	if c := rep.Checks["cache"]; c.Status != StatusFail || c.Error != "cache down" || c.Critical {
+		t.Errorf("cache result: %+v", c)
+	}
+
+	atomic.StoreInt32(&dbDown, 1)
+	code, rep = serve(r.ReadinessHandler())
+	if code != http.StatusServiceUnavailable || rep.Status != StatusFail || rep.Checks["db"].Error != "connection refused" {
+		t.Errorf("readiness with db down: %d %+v", code, rep)
+	}
+
+	// liveness doesn't care about the db
+	if code, _ := serve(r.LivenessHandler()); code != 200 {
+		t.Errorf("liveness with db down: %d", code)
+	}
+}
+
+func TestRegistry_timeout(t *testing.T) {
+	r := newTestRegistry(t, SetTimeout(time.Second))
+	block := make(chan struct{})
+	defer close(block)
+	r.Register(Check{Name: "stuck", Critical: true, Timeout: 20 * time.Millisecond, Check: func(context.Context) error {
+		<-block // ignores the context
+		return nil
+	}})
+	r.Register(Check{Name: "panics", Check: func(context.Context) error { panic("oops") }})
+
+	start := time.Now()
+	rep := r.Run(context.Background(), false)
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("timeout not enforced: %s", time.Since(start))
+	}
+	if rep.Status != StatusFail || rep.Checks["stuck"].Error != "health: check timed out: context deadline exceeded" {
+		t.Errorf("got %+v", rep)
+	}
+	if rep.Checks["panics"].Error != "health: check panicked: oops" {
+		t.Errorf("got %+v", rep.Checks["panics"])
+	}
+}
+
+func TestRegistry_cache(t *testing.T) {
+	r := newTestRegistry(t, SetCacheTTL(time.Minute))
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time { return now }
+
+	var runs int32
+	r.Register(Check{Name: "counted", Check: func(context.Context) error {
+		atomic.AddInt32(&runs, 1)
+		return nil
+	}})
+
+	for i := 0; i < 5; i++ {
+		r.Run(context.Background(), false)
+	}
+	if runs != 1 {
+		t.Errorf("expected one run, got %d", runs)
+	}
+
+	now = now.Add(2 * time.Minute)
+	r.Run(context.Background(), false)
+	if runs != 2 {
+		t.Errorf("expected a new run after the ttl, got %d", runs)
+	}
+
+	// results of canceled requests aren't cached
+	r.Register(Check{Name: "ctx", Check: func(ctx context.Context) error { return ctx.Err() }})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if rep := r.Run(ctx, false); rep.Checks["ctx"].Status != StatusFail {
+		t.Errorf("canceled check passed: %+v", rep)
+	}
+	if rep := r.Run(context.Background(), false); rep.Checks["ctx"].Status != StatusOK {
+		t.Errorf("canceled result was cached: %+v", rep)
+	}
+}
+
+func TestRegistry_process(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh binary:", err)
+	}
+	conn, err := proc.StartStdioProcess(sh, ioutil.Discard, "-c", "read line")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := newTestRegistry(t, SetCacheTTL(0))
+	if err := r.Register(Check{Name: "worker", Check: conn.Check, Critical: true, Liveness: true}); err != nil {
+		t.Fatal(err)
+	}
+	if code, _ := serve(r.LivenessHandler()); code != 200 {
+		t.Errorf("running process: %d", code)
+	}
+
+	conn.Write([]byte("bye\n"))
+	<-conn.Done()
+	code, rep := serve(r.LivenessHandler())
+	if code != http.StatusServiceUnavailable || rep.Checks["worker"].Error != proc.ErrExited.Error() {
+		t.Errorf("exited process: %d %+v", code, rep)
+	}
+}
+
+func TestRegister_invalid(t *testing.T) {
+	r := newTestRegistry(t)
+	if err := r.Register(Check{Check: ok}); err == nil {
+		t.Error("check without name accepted")
+	}
+	if err := r.Register(Check{Name: "x"}); err == nil {
+		t.Error("check without function accepted")
+	}
+	r.Register(Check{Name: "x", Check: ok})
+	if err := r.Register(Check{Name: "x", Check: ok}); err == nil {
+		t.Error("duplicate name accepted")
+	}
+	if _, err := New(SetTimeout(0)); err == nil {
+		t.Error("zero timeout accepted")
+	}
+}
@@ -0,0 +1,44 @@
+package health
+
+import (
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+)
+
+// Option is a function that changes a Registry during initialization
+type Option func(*Registry) error
+
+// SetLogger sets the logger for failing and recovering checks
+func SetLogger(l kitlog.Logger) Option {
+	return func(r *Registry) error {
+		if l == nil {
+			return errors.New("nil logger passed")
+		}
+		r.log = l
+		return nil
+	}
+}
+
+// SetTimeout sets how long a check may take by default
+func SetTimeout(d time.Duration) Option {
+	return func(r *Registry) error {
+		if d <= 0 {
+			return errors.New("timeout needs to be positive")
+		}
+		r.timeout = d
+		return nil
+	}
+}
+
+// SetCacheTTL sets how long results are reused. Zero disables caching.
+func SetCacheTTL(d time.Duration) Option {
+	return func(r *Registry) error {
+		if d < 0 {
+			return errors.New("cache ttl can't be negative")
+		}
+		r.cacheTTL = d
+		return nil
+	}
+}
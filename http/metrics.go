@@ -0,0 +1,339 @@
+package http
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.mindeco.de/logging/countconn"
+)
+
+// DefaultBuckets are the upper bounds of the latency histogram, in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Metrics records request counts, latencies and sizes and exposes them in the Prometheus text format.
+//
+//	m, _ := NewMetrics(SetRouteLabel(func(r *http.Request) string { return routeOf(r) }))
+//	http.Handle("/metrics", m.Handler())
+//	http.Handle("/", NewChain(m.Middleware).Then(app))
+type Metrics struct {
+	route   func(*http.Request) string
+	buckets []float64
+
+	inFlight int64 // atomic
+	connRx   int64 // atomic
+	connTx   int64 // atomic
+
+	mu        sync.Mutex
+	requests  map[requestKey]uint64
+	durations map[durationKey]*histogram
+	reqBytes  map[string]uint64
+	respBytes map[string]uint64
+}
+
+type requestKey struct {
+	method, route string
+	status        int
+}
+
+type durationKey struct {
+	method, route string
+}
+
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// MetricsOption is a function that changes Metrics during initialization
+type MetricsOption func(*Metrics) error
+
+// SetRouteLabel sets the function that maps a request to its route label.
+// It should return a small, fixed set of values, like the route pattern without its parameters.
+// Without it all requests share the route "other", as paths are chosen by the clients
+// and would create a new series for every one of them.
+func SetRouteLabel(fn func(*http.Request) string) MetricsOption {
+	return func(m *Metrics) error {
+		if fn == nil {
+			return errors.New("route label function can't be nil")
+		}
+		m.route = fn
+		return nil
+	}
+}
+
+// SetBuckets sets the upper bounds of the latency histogram, in seconds
+func SetBuckets(buckets ...float64) MetricsOption {
+	return func(m *Metrics) error {
+		if len(buckets) == 0 {
+			return errors.New("need at least one bucket")
+		}
+		b := append([]float64(nil), buckets...)
+		if !sort.Float64sAreSorted(b) {
+			return errors.New("buckets need to be sorted")
+		}
+		m.buckets = b
+		return nil
+	}
+}
+
+// NewMetrics returns an empty set of metrics
+func NewMetrics(opts ...MetricsOption) (*Metrics, error) {
+	m := &Metrics{
+		requests:  make(map[requestKey]uint64),
+		durations: make(map[durationKey]*histogram),
+		reqBytes:  make(map[string]uint64),
+		respBytes: make(map[string]uint64),
+	}
+	for _, o := range opts {
+		if err := o(m); err != nil {
+			return nil, err
+		}
+	}
+	if m.route == nil {
+		m.route = func(*http.Request) string { return "other" }
+	}
+	if m.buckets == nil {
+		m.buckets = DefaultBuckets
+	}
+	return m, nil
+}
+
+// knownMethods are used as method labels, everything else is counted as "OTHER"
+var knownMethods = map[string]bool{
+	http.MethodGet:     true,
+	http.MethodHead:    true,
+	http.MethodPost:    true,
+	http.MethodPut:     true,
+	http.MethodPatch:   true,
+	http.MethodDelete:  true,
+	http.MethodConnect: true,
+	http.MethodOptions: true,
+	http.MethodTrace:   true,
+}
+
+func methodLabel(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+	return "OTHER"
+}
+
+// Middleware records every request that passes through it
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&m.inFlight, 1)
+		defer atomic.AddInt64(&m.inFlight, -1)
+
+		var body *countconn.Reader
+		if r.Body != nil && r.Body != http.NoBody {
+			body = countconn.NewReader(r.Body)
+			r.Body = readCloser{body, r.Body}
+		}
+
+		start := time.Now()
+		rec := newResponseRecorder(w)
+		next.ServeHTTP(rec, r)
+
+		var read int64
+		if body != nil {
+			read = body.N()
+		}
+		m.observe(methodLabel(r.Method), m.route(r), rec.status, time.Since(start), read, rec.bytes)
+	})
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func (m *Metrics) observe(method, route string, status int, took time.Duration, read, written int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests[requestKey{method, route, status}]++
+	m.reqBytes[route] += uint64(read)
+	m.respBytes[route] += uint64(written)
+
+	dk := durationKey{method, route}
+	h, ok := m.durations[dk]
+	if !ok {
+		h = &histogram{counts: make([]uint64, len(m.buckets))}
+		m.durations[dk] = h
+	}
+	secs := took.Seconds()
+	if i := sort.SearchFloat64s(m.buckets, secs); i < len(m.buckets) {
+		h.counts[i]++
+	}
+	h.sum += secs
+	h.count++
+}
+
+// WrapListener counts the bytes of all connections accepted by l.
+// The counts are added to the metrics once a connection is closed.
+func (m *Metrics) WrapListener(l net.Listener) net.Listener {
+	return metricsListener{Listener: l, m: m}
+}
+
+type metricsListener struct {
+	net.Listener
+	m *Metrics
+}
+
+func (l metricsListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &metricsConn{Conn: c, m: l.m, r: countconn.NewReader(c), w: countconn.NewWriter(c)}, nil
+}
+
+type metricsConn struct {
+	net.Conn
+	m    *Metrics
+	r    *countconn.Reader
+	w    *countconn.Writer
+	once sync.Once
+}
+
+func (c *metricsConn) Read(p []byte) (int, error)  { return c.r.Read(p) }
+func (c *metricsConn) Write(p []byte) (int, error) { return c.w.Write(p) }
+
+func (c *metricsConn) Close() error {
+	c.once.Do(func() {
+		atomic.AddInt64(&c.m.connRx, c.r.N())
+		atomic.AddInt64(&c.m.connTx, c.w.N())
+	})
+	return c.Conn.Close()
+}
+
+// Handler serves the metrics in the Prometheus text exposition format
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		m.WriteTo(bw)
+		bw.Flush()
+	})
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format to w
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ew := &errWriter{w: w}
+
+	ew.header("http_requests_total", "counter", "Number of finished HTTP requests.")
+	reqKeys := make([]requestKey, 0, len(m.requests))
+	for k := range m.requests {
+		reqKeys = append(reqKeys, k)
+	}
+	sort.Slice(reqKeys, func(i, j int) bool {
+		a, b := reqKeys[i], reqKeys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.status < b.status
+	})
+	for _, k := range reqKeys {
+		ew.printf("http_requests_total{method=%s,route=%s,status=\"%d\"} %d\n", quoteLabel(k.method), quoteLabel(k.route), k.status, m.requests[k])
+	}
+
+	ew.header("http_requests_in_flight", "gauge", "Number of HTTP requests currently being served.")
+	ew.printf("http_requests_in_flight %d\n", atomic.LoadInt64(&m.inFlight))
+
+	ew.header("http_request_duration_seconds", "histogram", "Latency of HTTP requests.")
+	durKeys := make([]durationKey, 0, len(m.durations))
+	for k := range m.durations {
+		durKeys = append(durKeys, k)
+	}
+	sort.Slice(durKeys, func(i, j int) bool {
+		a, b := durKeys[i], durKeys[j]
+		if a.route != b.route {
+			return a.route < b.route
+		}
+		return a.method < b.method
+	})
+	for _, k := range durKeys {
+		h := m.durations[k]
+		labels := fmt.Sprintf("method=%s,route=%s", quoteLabel(k.method), quoteLabel(k.route))
+		var cum uint64
+		for i, b := range m.buckets {
+			cum += h.counts[i]
+			ew.printf("http_request_duration_seconds_bucket{%s,le=\"%s\"} %d\n", labels, formatFloat(b), cum)
+		}
+		ew.printf("http_request_duration_seconds_bucket{%s,le=\"+Inf\"} %d\n", labels, h.count)
+		ew.printf("http_request_duration_seconds_sum{%s} %s\n", labels, formatFloat(h.sum))
+		ew.printf("http_request_duration_seconds_count{%s} %d\n", labels, h.count)
+	}
+
+	for _, c := range []struct {
+		name, help string
+		values     map[string]uint64
+	}{
+		{"http_request_size_bytes_total", "Bytes read from request bodies.", m.reqBytes},
+		{"http_response_size_bytes_total", "Bytes written to response bodies.", m.respBytes},
+	} {
+		ew.header(c.name, "counter", c.help)
+		routes := make([]string, 0, len(c.values))
+		for r := range c.values {
+			routes = append(routes, r)
+		}
+		sort.Strings(routes)
+		for _, r := range routes {
+			ew.printf("%s{route=%s} %d\n", c.name, quoteLabel(r), c.values[r])
+		}
+	}
+
+	ew.header("http_connection_received_bytes_total", "counter", "Bytes received on closed connections.")
+	ew.printf("http_connection_received_bytes_total %d\n", atomic.LoadInt64(&m.connRx))
+	ew.header("http_connection_sent_bytes_total", "counter", "Bytes sent on closed connections.")
+	ew.printf("http_connection_sent_bytes_total %d\n", atomic.LoadInt64(&m.connTx))
+
+	return ew.n, ew.err
+}
+
+// errWriter keeps the first error and the number of written bytes
+type errWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (ew *errWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(ew.w, format, args...)
+	ew.n += int64(n)
+	ew.err = err
+}
+
+func (ew *errWriter) header(name, typ, help string) {
+	ew.printf("# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quoteLabel(v string) string {
+	return `"` + labelEscaper.Replace(v) + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
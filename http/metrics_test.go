@@ -0,0 +1,169 @@
+package http
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// firstSegment is a route label for the tests, e.g. "/api" for "/api/users/23"
+func firstSegment(r *http.Request) string {
+	p := strings.TrimPrefix(r.URL.Path, "/")
+	if i := strings.Index(p, "/"); i >= 0 {
+		p = p[:i]
+	}
+	return "/" + p
+}
+
+func TestMetrics(t *testing.T) {
+	m, err := NewMetrics(SetBuckets(0.5, 1), SetRouteLabel(firstSegment))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(ioutil.Discard, r.Body)
+		if strings.HasPrefix(r.URL.Path, "/missing") {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, "hello")
+	}))
+
+	for _, req := range []*http.Request{
+		httptest.NewRequest("GET", "/api/users/1", nil),
+		httptest.NewRequest("GET", "/api/users/2", nil),
+		httptest.NewRequest("POST", "/api/users", strings.NewReader("name=test")),
+		httptest.NewRequest("GET", "/missing/thing", nil),
+	} {
+		h.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"# TYPE http_requests_total counter\n",
+		`http_requests_total{method="GET",route="/api",status="200"} 2` + "\n",
+		`http_requests_total{method="POST",route="/api",status="200"} 1` + "\n",
+		`http_requests_total{method="GET",route="/missing",status="404"} 1` + "\n",
+		"http_requests_in_flight 0\n",
+		"# TYPE http_request_duration_seconds histogram\n",
+		`http_request_duration_seconds_bucket{method="GET",route="/api",le="0.5"} 2` + "\n",
+		`http_request_duration_seconds_bucket{method="GET",route="/api",le="1"} 2` + "\n",
+		`http_request_duration_seconds_bucket{method="GET",route="/api",le="+Inf"} 2` + "\n",
+		`http_request_duration_seconds_count{method="GET",route="/api"} 2` + "\n",
+		`http_request_size_bytes_total{route="/api"} 9` + "\n",
+		`http_response_size_bytes_total{route="/api"} 15` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestMetrics_boundedLabels(t *testing.T) {
+	m, err := NewMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	series := func() int {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		return len(m.requests) + len(m.durations) + len(m.reqBytes) + len(m.respBytes)
+	}
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("FOO", "/", nil))
+	before := series()
+	for i := 0; i < 100; i++ {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", fmt.Sprintf("/p%d/x", i), nil))
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(fmt.Sprintf("M%d", i), fmt.Sprintf("/q%d", i), nil))
+	}
+	if after := series(); after != before {
+		t.Errorf("series grew from %d to %d", before, after)
+	}
+
+	var buf bytes.Buffer
+	if _, err := m.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`http_requests_total{method="GET",route="other",status="200"} 101` + "\n",
+		`http_requests_total{method="OTHER",route="other",status="200"} 101` + "\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q in\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestMetrics_routeLabel(t *testing.T) {
+	m, err := NewMetrics(SetRouteLabel(func(r *http.Request) string { return "weird\"route\n" }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Middleware(http.NotFoundHandler()).ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("wrong content type %q", rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Body.String(), `route="weird\"route\n",status="404"`) {
+		t.Errorf("label not escaped:\n%s", rec.Body.String())
+	}
+}
+
+func TestMetrics_listener(t *testing.T) {
+	m, err := NewMetrics()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewUnstartedServer(m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	})))
+	srv.Listener = m.WrapListener(l)
+	srv.Start()
+
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	srv.Close()
+
+	var buf bytes.Buffer
+	m.WriteTo(&buf)
+	out := buf.String()
+	if strings.Contains(out, "http_connection_received_bytes_total 0\n") || strings.Contains(out, "http_connection_sent_bytes_total 0\n") {
+		t.Errorf("connection bytes not counted:\n%s", out)
+	}
+}
+
+func TestNewMetrics_invalid(t *testing.T) {
+	if _, err := NewMetrics(SetBuckets(1, 0.5)); err == nil {
+		t.Error("unsorted buckets accepted")
+	}
+	if _, err := NewMetrics(SetBuckets()); err == nil {
+		t.Error("no buckets accepted")
+	}
+	if _, err := NewMetrics(SetRouteLabel(nil)); err == nil {
+		t.Error("nil route func accepted")
+	}
+}
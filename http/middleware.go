@@ -0,0 +1,212 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	kitlog "github.com/go-kit/kit/log"
+	"github.com/pkg/errors"
+
+	"go.mindeco.de/logging"
+)
+
+// HeaderRequestID is the header RequestID reads and sets
+const HeaderRequestID = "X-Request-Id"
+
+type requestIDCtxKey struct{}
+
+// RequestID makes sure every request has an id. A well-formed id sent by the client is kept,
+// otherwise a random one is created. The id is set as a response header, stored in the context
+// (see RequestIDFromContext) and added to the logger of logging.InjectHandler, if it ran before.
+func RequestID() MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(HeaderRequestID)
+			if !validRequestID(id) {
+				id = newRequestID()
+				r.Header.Set(HeaderRequestID, id)
+			}
+			w.Header().Set(HeaderRequestID, id)
+
+			ctx := context.WithValue(r.Context(), requestIDCtxKey{}, id)
+			if l := logging.FromContext(ctx); l != nil {
+				ctx = logging.NewContext(ctx, kitlog.With(l, "requestID", id))
+			}
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestIDFromContext returns the id set by RequestID or an empty string
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDCtxKey{}).(string)
+	return id
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(errors.Wrap(err, "cryptix/http: failed to read random bytes"))
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// RealIP replaces r.RemoteAddr with the address of the client, if the request came through one of the trusted proxies.
+// X-Forwarded-For is walked from the right, skipping trusted addresses, X-Real-IP is used if it isn't set.
+// Requests from other addresses are left alone, so that clients can't spoof their address.
+// trusted are CIDRs like 10.0.0.0/8 or single IPs.
+func RealIP(trusted ...string) (MiddlewareFunc, error) {
+	var nets []*net.IPNet
+	for _, t := range trusted {
+		if !strings.Contains(t, "/") {
+			if ip := net.ParseIP(t); ip != nil && ip.To4() != nil {
+				t += "/32"
+			} else {
+				t += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(t)
+		if err != nil {
+			return nil, errors.Wrapf(err, "cryptix/http: invalid trusted proxy %q", t)
+		}
+		nets = append(nets, n)
+	}
+
+	isTrusted := func(addr string) bool {
+		ip := net.ParseIP(strings.TrimSpace(addr))
+		if ip == nil {
+			return false
+		}
+		for _, n := range nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			host, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				host = r.RemoteAddr
+			}
+			if isTrusted(host) {
+				if ip := forwardedFor(r.Header, isTrusted); ip != "" {
+					r.RemoteAddr = ip
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	}, nil
+}
+
+func forwardedFor(h http.Header, isTrusted func(string) bool) string {
+	var hops []string
+	for _, v := range h["X-Forwarded-For"] {
+		hops = append(hops, strings.Split(v, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			// garbage in the chain, nothing left of it can be trusted
+			return ""
+		}
+		if !isTrusted(hop) {
+			return hop
+		}
+	}
+	if len(hops) > 0 {
+		// all hops are trusted, the leftmost one is the origin
+		return strings.TrimSpace(hops[0])
+	}
+	if ip := strings.TrimSpace(h.Get("X-Real-Ip")); net.ParseIP(ip) != nil {
+		return ip
+	}
+	return ""
+}
+
+// MaxBodySize limits request bodies to n bytes.
+// Requests that announce a larger body are rejected with http.StatusRequestEntityTooLarge,
+// others fail to read past the limit.
+func MaxBodySize(n int64) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > n {
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if r.Body != nil {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Timeout cancels the request context after d and answers with http.StatusServiceUnavailable
+// if the handler didn't finish by then. See http.TimeoutHandler for the details.
+func Timeout(d time.Duration) MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.TimeoutHandler(next, d, http.StatusText(http.StatusServiceUnavailable))
+	}
+}
+
+// DefaultSecurityHeaders are the headers SecurityHeaders sets, unless overwritten.
+// Strict-Transport-Security is only sent on TLS connections.
+var DefaultSecurityHeaders = map[string]string{
+	"X-Content-Type-Options":    "nosniff",
+	"X-Frame-Options":           "DENY",
+	"Referrer-Policy":           "strict-origin-when-cross-origin",
+	"Content-Security-Policy":   "default-src 'self'",
+	"Strict-Transport-Security": "max-age=31536000; includeSubDomains",
+}
+
+// SecurityHeaders sets DefaultSecurityHeaders on every response.
+// Entries in overrides replace the defaults, an empty value drops the header.
+func SecurityHeaders(overrides map[string]string) MiddlewareFunc {
+	hdrs := make(map[string]string)
+	for k, v := range DefaultSecurityHeaders {
+		hdrs[http.CanonicalHeaderKey(k)] = v
+	}
+	for k, v := range overrides {
+		k = http.CanonicalHeaderKey(k)
+		if v == "" {
+			delete(hdrs, k)
+			continue
+		}
+		hdrs[k] = v
+	}
+	hsts := hdrs["Strict-Transport-Security"]
+	delete(hdrs, "Strict-Transport-Security")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			h := w.Header()
+			for k, v := range hdrs {
+				h.Set(k, v)
+			}
+			if hsts != "" && r.TLS != nil {
+				h.Set("Strict-Transport-Security", hsts)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFromContext(r.Context())
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if len(seen) != 32 || rec.Header().Get(HeaderRequestID) != seen {
+		t.Errorf("generated id wrong: %q %q", seen, rec.Header().Get(HeaderRequestID))
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(HeaderRequestID, "abc-123")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if seen != "abc-123" {
+		t.Errorf("client id not kept: %q", seen)
+	}
+
+	req = httptest.NewRequest("GET", "/", nil)
+	req.Header.Set(HeaderRequestID, "bad id\n")
+	h.ServeHTTP(httptest.NewRecorder(), req)
+	if seen == "bad id\n" || len(seen) != 32 {
+		t.Errorf("invalid id kept: %q", seen)
+	}
+}
+
+func TestRealIP(t *testing.T) {
+	mw, err := RealIP("10.0.0.0/8", "192.168.1.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var seen string
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = r.RemoteAddr
+	}))
+
+	tcases := []struct {
+		remote, xff, xri, want string
+	}{
+		{"10.1.2.3:4567", "1.2.3.4", "", "1.2.3.4"},
+		{"10.1.2.3:4567", "6.6.6.6, 1.2.3.4, 10.0.0.5", "", "1.2.3.4"},
+		{"192.168.1.1:80", "10.0.0.1, 10.0.0.2", "", "10.0.0.1"},
+		{"10.1.2.3:4567", "", "5.6.7.8", "5.6.7.8"},
+		{"10.1.2.3:4567", "nope, 10.0.0.1", "", "10.1.2.3:4567"},
+		{"8.8.8.8:1234", "1.2.3.4", "5.6.7.8", "8.8.8.8:1234"},
+	}
+	for i, tc := range tcases {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.RemoteAddr = tc.remote
+		if tc.xff != "" {
+			req.Header.Set("X-Forwarded-For", tc.xff)
+		}
+		if tc.xri != "" {
+			req.Header.Set("X-Real-Ip", tc.xri)
+		}
+		h.ServeHTTP(httptest.NewRecorder(), req)
+		if seen != tc.want {
+			t.Errorf("%d: got %q", i, seen)
+		}
+	}
+
+	if _, err := RealIP("not-a-net"); err == nil {
+		t.Error("invalid proxy accepted")
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	h := MaxBodySize(5)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := ioutil.ReadAll(r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	}))
+
+	for body, want := range map[string]int{"12345": 200, "123456": 413} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		if rec.Code != want {
+			t.Errorf("%q: got %d", body, rec.Code)
+		}
+
+		// unknown length
+		req := httptest.NewRequest("POST", "/", ioutil.NopCloser(strings.NewReader(body)))
+		req.ContentLength = -1
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != want {
+			t.Errorf("%q streamed: got %d", body, rec.Code)
+		}
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	h := Timeout(20 * time.Millisecond)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d", rec.Code)
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	h := SecurityHeaders(map[string]string{
+		"x-frame-options":         "SAMEORIGIN",
+		"Content-Security-Policy": "",
+	})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	hdr := rec.Header()
+	if hdr.Get("X-Frame-Options") != "SAMEORIGIN" || hdr.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("wrong headers: %v", hdr)
+	}
+	if _, has := hdr["Content-Security-Policy"]; has {
+		t.Error("dropped header still set")
+	}
+	if hdr.Get("Strict-Transport-Security") != "" {
+		t.Error("HSTS sent over plain http")
+	}
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.TLS = &tls.ConnectionState{}
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Header().Get("Strict-Transport-Security") == "" {
+		t.Error("HSTS missing over TLS")
+	}
+}
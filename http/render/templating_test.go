@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/PuerkitoBio/goquery"
+	"go.mindeco.de/goutils/fixture"
 	"go.mindeco.de/logging"
 	"go.mindeco.de/logging/logtest"
 )
@@ -49,6 +50,30 @@ func TestRender(t *testing.T) {
 	}
 }
 
+func TestRenderGolden(t *testing.T) {
+	logging.SetupLogging(logtest.Logger("Render", t))
+	log := logging.Logger("TestRenderGolden")
+	r, err := New(http.Dir("tests"),
+		SetLogger(log),
+		BaseTemplates("subdir/base2.tmpl", "extra.tmpl"),
+		AddTemplates("test1.tmpl", "test2.tmpl"),
+	)
+	if err != nil {
+		t.Fatal("New() failed", err)
+	}
+	for _, tpl := range []string{"test1.tmpl", "test2.tmpl"} {
+		rw := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/test", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := r.Render(rw, req, tpl, http.StatusOK, nil); err != nil {
+			t.Fatal(err)
+		}
+		fixture.Golden(t, tpl, rw.Body.Bytes())
+	}
+}
+
 func TestFuncMap(t *testing.T) {
 	logging.SetupLogging(logtest.Logger("Render", t))
 	log := logging.Logger("TestFuncMap")
@@ -1,6 +1,8 @@
 package http
 
 import (
+	"crypto/subtle"
+	stderrors "errors"
 	"net/http"
 
 	kitlog "github.com/go-kit/kit/log"
@@ -10,22 +12,31 @@ type MiddlewareFunc func(next http.Handler) http.Handler
 
 type HandlerFuncWithErr func(w http.ResponseWriter, r *http.Request) error
 
+// WrapWithError turns f into a http.HandlerFunc which logs the errors f returns.
+// An *HTTPError anywhere in the chain of the error picks the status and message of the response,
+// every other error is answered with a plain 500. The internal cause is never sent to the client.
+// If the client accepts JSON, the response is {"status": 404, "error": "Not Found"}.
 func WrapWithError(f HandlerFuncWithErr, log kitlog.Logger) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if err := f(w, r); err != nil {
-			log.Log("event", "error", "msg", "could not serve HTTP request", "err", err, "path", r.URL.Path)
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			var he *HTTPError
+			if !stderrors.As(err, &he) {
+				he = Internal(err)
+			}
+			log.Log("event", "error", "msg", "could not serve HTTP request", "status", he.Status, "err", err, "path", r.URL.Path)
+			writeError(w, r, he)
 		}
 	}
 }
 
 // Authorize does a very simple header check against a wanted value
-// it returns http.StatusUnauthorized if it's false
+// it returns http.StatusUnauthorized if it's false.
+// See Authorizer for multiple keys, bearer tokens and signed requests.
 func Authorize(headerName, wantHeader string) MiddlewareFunc {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			hv := r.Header.Get(headerName)
-			if hv != wantHeader {
+			if subtle.ConstantTimeCompare([]byte(hv), []byte(wantHeader)) != 1 {
 				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
 				return
 			}
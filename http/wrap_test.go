@@ -0,0 +1,105 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	kitlog "github.com/go-kit/kit/log"
+	pkgerrors "github.com/pkg/errors"
+)
+
+// testLogger logs synchronously into a buffer that is printed if the test failed
+func testLogger(t *testing.T) kitlog.Logger {
+	var buf bytes.Buffer
+	t.Cleanup(func() {
+		if t.Failed() {
+			t.Log(buf.String())
+		}
+	})
+	return kitlog.NewLogfmtLogger(kitlog.NewSyncWriter(&buf))
+}
+
+func TestWrapWithError(t *testing.T) {
+	secret := errors.New("pq: relation users does not exist")
+
+	tcases := []struct {
+		err    error
+		status int
+		body   string
+	}{
+		{nil, http.StatusOK, "fine"},
+		{secret, http.StatusInternalServerError, "Internal Server Error\n"},
+		{NotFound(secret), http.StatusNotFound, "Not Found\n"},
+		{BadRequest(nil), http.StatusBadRequest, "Bad Request\n"},
+		{fmt.Errorf("loading user: %w", Conflict(secret)), http.StatusConflict, "Conflict\n"},
+		{pkgerrors.Wrap(NotFound(secret), "loading user"), http.StatusNotFound, "Not Found\n"},
+		{pkgerrors.WithMessage(pkgerrors.Wrap(Forbidden(nil), "loading user"), "handler"), http.StatusForbidden, "Forbidden\n"},
+		{NewHTTPError(http.StatusUnprocessableEntity, "name is required", secret), http.StatusUnprocessableEntity, "name is required\n"},
+	}
+
+	l := testLogger(t)
+	for i, tc := range tcases {
+		h := WrapWithError(func(w http.ResponseWriter, r *http.Request) error {
+			if tc.err == nil {
+				w.Write([]byte("fine"))
+			}
+			return tc.err
+		}, l)
+
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest("GET", "/", nil))
+		if rec.Code != tc.status || rec.Body.String() != tc.body {
+			t.Errorf("%d: got %d %q", i, rec.Code, rec.Body.String())
+		}
+		if strings.Contains(rec.Body.String(), "pq:") {
+			t.Errorf("%d: internal error leaked", i)
+		}
+	}
+}
+
+func TestWrapWithError_json(t *testing.T) {
+	l := testLogger(t)
+	h := WrapWithError(func(w http.ResponseWriter, r *http.Request) error {
+		return Forbidden(errors.New("user 23 is not an admin"))
+	}, l)
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Accept", "text/html;q=0.9, application/json")
+	rec := httptest.NewRecorder()
+	h(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("wrong status %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/json") {
+		t.Errorf("wrong content type %q", ct)
+	}
+	var body map[string]interface{}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["error"] != "Forbidden" || body["status"] != float64(403) {
+		t.Errorf("wrong body %v", body)
+	}
+}
+
+func TestHTTPError_unwrap(t *testing.T) {
+	cause := errors.New("cause")
+	err := fmt.Errorf("ctx: %w", NotFound(cause))
+	if !errors.Is(err, cause) {
+		t.Error("cause not found")
+	}
+	var he *HTTPError
+	if !errors.As(err, &he) || he.Status != http.StatusNotFound {
+		t.Errorf("wrong error %v", he)
+	}
+	if he.Error() != "404 Not Found: cause" {
+		t.Errorf("wrong message %q", he.Error())
+	}
+}
@@ -5,18 +5,40 @@ import (
 	"io"
 	stdlog "log"
 	"os"
+	"time"
 
 	kitlog "github.com/go-kit/kit/log"
 	"github.com/pkg/errors"
 )
 
-var closeChan chan<- os.Signal
+var (
+	closeChan    chan<- os.Signal
+	closeWait    <-chan struct{}
+	closeTimeout time.Duration
+)
 
 // SetCloseChan sets a signal channel that is sent to when CheckFatal is used
 func SetCloseChan(c chan<- os.Signal) {
 	closeChan = c
 }
 
+// CloseChan returns the channel set with SetCloseChan
+func CloseChan() chan<- os.Signal {
+	return closeChan
+}
+
+// SetCloseWait makes CheckFatal wait until done is closed, or timeout passed, after it sent on the close channel.
+// This gives the receiver of the close channel the chance to shut down cleanly before the process exits.
+func SetCloseWait(done <-chan struct{}, timeout time.Duration) {
+	closeWait = done
+	closeTimeout = timeout
+}
+
+// CloseWait returns the channel and timeout set with SetCloseWait
+func CloseWait() (<-chan struct{}, time.Duration) {
+	return closeWait, closeTimeout
+}
+
 // CheckFatal exits the process if err != nil
 func CheckFatal(err error) {
 	if err != nil {
@@ -29,9 +51,18 @@ func CheckFatal(err error) {
 		if err := LogPanicWithStack(l, "CheckFatal", err); err != nil {
 			panic(errors.Wrap(err, "CheckFatal could not dump error"))
 		}
+		// the receiver may reset them while it shuts down
+		closeChan, closeWait, closeTimeout := closeChan, closeWait, closeTimeout
 		if closeChan != nil {
 			l.Log("check", "notice", "msg", "Sending close message")
 			closeChan <- os.Interrupt
+			if closeWait != nil {
+				select {
+				case <-closeWait:
+				case <-time.After(closeTimeout):
+					l.Log("check", "notice", "msg", "timed out waiting for shutdown")
+				}
+			}
 		}
 		os.Exit(1)
 	}
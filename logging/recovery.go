@@ -1,11 +1,14 @@
 package logging
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"path/filepath"
 	"runtime/debug"
+	"strings"
 
 	"github.com/davecgh/go-spew/spew"
 	"github.com/pkg/errors"
@@ -48,20 +51,47 @@ func LogPanicWithStack(log Interface, location string, r interface{}, vals ...in
 		err = errors.Errorf("unkown type(%T) error: %v", r, r)
 	}
 	os.Mkdir("panics", os.ModePerm)
-	b, tmpErr := ioutil.TempFile("panics", location)
+	// the dump is written to a hidden temp file and renamed once it's complete,
+	// so that a crash while writing it doesn't leave a truncated dump behind
+	b, tmpErr := ioutil.TempFile("panics", "."+location+"*.tmp")
 	if tmpErr != nil {
 		log.Log("event", "panic", "location", location, "err", err, "warning", "no temp file", "tmperr", tmpErr)
 		return errors.Wrapf(tmpErr, "LogPanic: failed to create httpRecovery log")
 	}
+	tmp := b.Name()
+	name := filepath.Join("panics", strings.TrimSuffix(strings.TrimPrefix(filepath.Base(tmp), "."), ".tmp"))
 
-	fmt.Fprintf(b, "warning! %s!\nError:\n%+v\n", location, err)
+	var dump bytes.Buffer
+	fmt.Fprintf(&dump, "warning! %s!\nError:\n%+v\n", location, err)
 	for i, v := range vals {
-		spew.Fdump(b, "val(%d): %#v\n", i, v)
+		spew.Fdump(&dump, "val(%d): %#v\n", i, v)
 	}
-	fmt.Fprintf(b, "\n\nCall Stack:\n%s", debug.Stack())
+	fmt.Fprintf(&dump, "\n\nCall Stack:\n%s", debug.Stack())
 
-	log.Log("event", "panic", "location", location, "panicLog", b.Name())
-	fmt.Fprintf(os.Stderr, "panicWithStack: wrote %s\n", b.Name())
+	if _, err := dump.WriteTo(b); err != nil {
+		b.Close()
+		os.Remove(tmp)
+		return errors.Wrap(err, "LogPanic: failed to write dump file")
+	}
+	if err := b.Sync(); err != nil {
+		b.Close()
+		os.Remove(tmp)
+		return errors.Wrap(err, "LogPanic: failed to sync dump file")
+	}
+	if err := b.Close(); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "LogPanic: failed to close dump file")
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return errors.Wrap(err, "LogPanic: failed to rename dump file")
+	}
+	if d, err := os.Open("panics"); err == nil {
+		d.Sync()
+		d.Close()
+	}
 
-	return errors.Wrap(b.Close(), "LogPanic: failed to close dump file")
+	log.Log("event", "panic", "location", location, "panicLog", name)
+	fmt.Fprintf(os.Stderr, "panicWithStack: wrote %s\n", name)
+	return nil
 }
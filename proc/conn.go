@@ -2,19 +2,31 @@ package proc
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+
+	"github.com/pkg/errors"
 )
 
+// ErrExited is returned by Check once the process exited without an error
+var ErrExited = errors.New("proc: process exited")
+
 type StdioConn struct {
 	io.ReadCloser
 	io.WriteCloser
-	waitErr chan error
+	state *procState
 }
 
-// Close calls Close on both closers
+// procState is shared by all copies of a StdioConn
+type procState struct {
+	done chan struct{} // closed once the process exited
+	err  error         // result of cmd.Wait, only read after done is closed
+}
+
+// Close calls Close on both closers and waits for the process to exit
 func (s StdioConn) Close() error {
 	if err := s.ReadCloser.Close(); err != nil {
 		return err
@@ -22,7 +34,27 @@ func (s StdioConn) Close() error {
 	if err := s.WriteCloser.Close(); err != nil {
 		return err
 	}
-	return <-s.waitErr
+	<-s.state.done
+	return s.state.err
+}
+
+// Done is closed once the process exited
+func (s StdioConn) Done() <-chan struct{} {
+	return s.state.done
+}
+
+// Check returns nil while the process is running.
+// Once it exited, it returns its exit error or ErrExited. It can be used as a health check.
+func (s StdioConn) Check(ctx context.Context) error {
+	select {
+	case <-s.state.done:
+		if s.state.err != nil {
+			return errors.Wrap(s.state.err, "proc: process exited")
+		}
+		return ErrExited
+	default:
+		return nil
+	}
 }
 
 func StartStdioProcess(path string, stderr io.Writer, args ...string) (*StdioConn, error) {
@@ -42,7 +74,7 @@ func StartStdioProcess(path string, stderr io.Writer, args ...string) (*StdioCon
 		return nil, err
 	}
 
-	conn.waitErr = make(chan error)
+	conn.state = &procState{done: make(chan struct{})}
 
 	if stderr == nil {
 		stderr, err := cmd.StderrPipe()
@@ -68,7 +100,8 @@ func StartStdioProcess(path string, stderr io.Writer, args ...string) (*StdioCon
 	}
 
 	go func() {
-		conn.waitErr <- cmd.Wait()
+		conn.state.err = cmd.Wait()
+		close(conn.state.done)
 	}()
 
 	return &conn, nil
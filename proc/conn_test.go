@@ -0,0 +1,69 @@
+package proc
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestStdioConn(t *testing.T) {
+	cat, err := exec.LookPath("cat")
+	if err != nil {
+		t.Skip("no cat binary:", err)
+	}
+
+	conn, err := StartStdioProcess(cat, ioutil.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Check(context.Background()); err != nil {
+		t.Fatal("running process failed check:", err)
+	}
+
+	if _, err := conn.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err := conn.Read(buf); err != nil || string(buf) != "hello" {
+		t.Fatalf("got %q %v", buf, err)
+	}
+
+	if err := conn.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.Check(context.Background()); !errors.Is(err, ErrExited) {
+		t.Errorf("expected ErrExited, got %v", err)
+	}
+	// closing again doesn't block
+	conn.Close()
+}
+
+func TestStdioConn_exitError(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh binary:", err)
+	}
+
+	conn, err := StartStdioProcess(sh, ioutil.Discard, "-c", "exit 3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-conn.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("process didn't exit")
+	}
+
+	err = conn.Check(context.Background())
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 3 {
+		t.Errorf("expected exit status 3, got %v", err)
+	}
+	if _, ok := errors.Cause(err).(*exec.ExitError); !ok {
+		t.Errorf("wrong cause: %T", errors.Cause(err))
+	}
+}